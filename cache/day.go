@@ -0,0 +1,24 @@
+package cache
+
+import "time"
+
+// DayKey returns the key a reading for the UTC day of at is cached under.
+func DayKey(at time.Time) string {
+	return at.UTC().Format("2006-01-02")
+}
+
+// Final reports whether the reading for the UTC day of at can no longer
+// change, which is the case for every day before today.
+func Final(at time.Time) bool {
+	return DayKey(at) < DayKey(time.Now())
+}
+
+// DayTTL returns how long a reading for the UTC day of at may be cached.
+// Readings of past days never change, so they do not expire, while today's
+// reading still can and is only kept for todayTTL.
+func DayTTL(at time.Time, todayTTL time.Duration) time.Duration {
+	if !Final(at) {
+		return todayTTL
+	}
+	return 0
+}
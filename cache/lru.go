@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LRU is a size bounded cache which evicts its least recently used entries
+// first. Entries may additionally expire after a TTL. It is safe for
+// concurrent use.
+type LRU struct {
+	hits   int64
+	misses int64
+
+	mu       sync.Mutex
+	capacity int
+	entries  *list.List
+	elements map[string]*list.Element
+}
+
+type entry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// Stats is a snapshot of an LRU's usage.
+type Stats struct {
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+	Size     int   `json:"size"`
+	Capacity int   `json:"capacity"`
+}
+
+func NewLRU(capacity int) *LRU {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LRU{
+		capacity: capacity,
+		entries:  list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.elements[key]
+	if ok && c.expired(el.Value.(*entry)) {
+		c.remove(el)
+		ok = false
+	}
+	if !ok {
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+
+	atomic.AddInt64(&c.hits, 1)
+	c.entries.MoveToFront(el)
+	return el.Value.(*entry).value, true
+}
+
+// Add stores value under key, evicting the least recently used entry if the
+// cache is full. A zero ttl means the entry never expires.
+func (c *LRU) Add(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if el, ok := c.elements[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		c.entries.MoveToFront(el)
+		return
+	}
+
+	c.elements[key] = c.entries.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	if c.entries.Len() > c.capacity {
+		c.remove(c.entries.Back())
+	}
+}
+
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries.Len()
+}
+
+func (c *LRU) Stats() Stats {
+	return Stats{
+		Hits:     atomic.LoadInt64(&c.hits),
+		Misses:   atomic.LoadInt64(&c.misses),
+		Size:     c.Len(),
+		Capacity: c.capacity,
+	}
+}
+
+func (c *LRU) expired(e *entry) bool {
+	return !e.expiresAt.IsZero() && time.Now().After(e.expiresAt)
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.entries.Remove(el)
+	delete(c.elements, el.Value.(*entry).key)
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUReturnsAddedValues(t *testing.T) {
+	c := NewLRU(2)
+	c.Add("a", 1, 0)
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	assert.Equal(t, Stats{Hits: 1, Misses: 1, Size: 1, Capacity: 2}, c.Stats())
+}
+
+func TestLRUEvictsLeastRecentlyUsedEntry(t *testing.T) {
+	c := NewLRU(2)
+	c.Add("a", 1, 0)
+	c.Add("b", 2, 0)
+	c.Get("a")
+	c.Add("c", 3, 0)
+
+	_, ok := c.Get("b")
+	assert.False(t, ok)
+
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRUExpiresEntriesAfterTTL(t *testing.T) {
+	c := NewLRU(2)
+	c.Add("a", 1, time.Millisecond)
+	c.Add("b", 2, 0)
+
+	time.Sleep(2 * time.Millisecond)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestLRUReplacesExistingEntry(t *testing.T) {
+	c := NewLRU(2)
+	c.Add("a", 1, 0)
+	c.Add("a", 2, 0)
+
+	v, _ := c.Get("a")
+	assert.Equal(t, 2, v)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestDayTTLOnlyExpiresToday(t *testing.T) {
+	assert.Equal(t, time.Duration(0), DayTTL(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), time.Minute))
+	assert.Equal(t, time.Minute, DayTTL(time.Now(), time.Minute))
+}
@@ -0,0 +1,158 @@
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/svranesevic/charlyedu/serviceerror"
+	log "go.uber.org/zap"
+)
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+var ErrOpen = serviceerror.New(serviceerror.Unavailable, "service is unavailable, circuit breaker is open")
+
+// Settings configures when a Breaker trips and recovers. It opens after
+// FailureThreshold consecutive failures, lets a single probe through once
+// Cooldown has passed, and closes again after SuccessThreshold consecutive
+// successful probes.
+type Settings struct {
+	FailureThreshold int
+	SuccessThreshold int
+	Cooldown         time.Duration
+}
+
+type Breaker struct {
+	name     string
+	settings Settings
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	successes int
+	openedAt  time.Time
+	probing   bool
+}
+
+func New(name string, settings Settings) *Breaker {
+	if settings.FailureThreshold < 1 {
+		settings.FailureThreshold = 1
+	}
+	if settings.SuccessThreshold < 1 {
+		settings.SuccessThreshold = 1
+	}
+	return &Breaker{name: name, settings: settings}
+}
+
+// State returns the breaker's current state. An open breaker whose cooldown
+// has passed reports HalfOpen, as the next call will be let through.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open && time.Since(b.openedAt) >= b.settings.Cooldown {
+		return HalfOpen
+	}
+	return b.state
+}
+
+// Execute runs fn unless the breaker is open, in which case it returns
+// ErrOpen straight away. The outcome of fn is not recorded if ctx is done by
+// the time it returns, as the caller giving up says nothing about the
+// dependency's health.
+func (b *Breaker) Execute(ctx context.Context, fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	if ctx.Err() != nil {
+		b.release()
+	} else {
+		b.record(err == nil)
+	}
+
+	return err
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		if time.Since(b.openedAt) < b.settings.Cooldown {
+			return ErrOpen
+		}
+		b.transition(HalfOpen)
+	}
+
+	if b.state == HalfOpen {
+		if b.probing {
+			return ErrOpen
+		}
+		b.probing = true
+	}
+
+	return nil
+}
+
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.probing = false
+	}
+}
+
+func (b *Breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		if success {
+			b.failures = 0
+		} else if b.failures++; b.failures >= b.settings.FailureThreshold {
+			b.transition(Open)
+		}
+	case HalfOpen:
+		b.probing = false
+		if !success {
+			b.transition(Open)
+		} else if b.successes++; b.successes >= b.settings.SuccessThreshold {
+			b.transition(Closed)
+		}
+	}
+}
+
+func (b *Breaker) transition(to State) {
+	log.S().Warnf("circuit breaker %s: %s -> %s", b.name, b.state, to)
+
+	b.state = to
+	b.failures = 0
+	b.successes = 0
+	b.probing = false
+	if to == Open {
+		b.openedAt = time.Now()
+	}
+}
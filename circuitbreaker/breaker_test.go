@@ -0,0 +1,93 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errUpstream = errors.New("upstream error")
+
+func fail() error {
+	return errUpstream
+}
+
+func succeed() error {
+	return nil
+}
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	b := New("test", Settings{FailureThreshold: 3, Cooldown: time.Minute})
+	ctx := context.Background()
+
+	b.Execute(ctx, fail)
+	b.Execute(ctx, fail)
+	b.Execute(ctx, succeed)
+	b.Execute(ctx, fail)
+	b.Execute(ctx, fail)
+	assert.Equal(t, Closed, b.State())
+
+	assert.Equal(t, errUpstream, b.Execute(ctx, fail))
+	assert.Equal(t, Open, b.State())
+
+	called := false
+	err := b.Execute(ctx, func() error {
+		called = true
+		return nil
+	})
+	assert.Equal(t, ErrOpen, err)
+	assert.False(t, called)
+}
+
+func TestBreakerClosesAfterSuccessfulProbes(t *testing.T) {
+	b := New("test", Settings{FailureThreshold: 1, SuccessThreshold: 2, Cooldown: time.Millisecond})
+	ctx := context.Background()
+
+	b.Execute(ctx, fail)
+	time.Sleep(2 * time.Millisecond)
+	assert.Equal(t, HalfOpen, b.State())
+
+	assert.Nil(t, b.Execute(ctx, succeed))
+	assert.Equal(t, HalfOpen, b.State())
+
+	assert.Nil(t, b.Execute(ctx, succeed))
+	assert.Equal(t, Closed, b.State())
+}
+
+func TestBreakerReopensOnFailedProbe(t *testing.T) {
+	b := New("test", Settings{FailureThreshold: 1, Cooldown: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	b.Execute(ctx, fail)
+	time.Sleep(20 * time.Millisecond)
+
+	assert.Equal(t, errUpstream, b.Execute(ctx, fail))
+	assert.Equal(t, Open, b.State())
+}
+
+func TestBreakerLetsSingleProbeThroughWhenHalfOpen(t *testing.T) {
+	b := New("test", Settings{FailureThreshold: 1, Cooldown: time.Millisecond})
+	ctx := context.Background()
+
+	b.Execute(ctx, fail)
+	time.Sleep(2 * time.Millisecond)
+
+	err := b.Execute(ctx, func() error {
+		assert.Equal(t, ErrOpen, b.Execute(ctx, succeed))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, Closed, b.State())
+}
+
+func TestBreakerIgnoresOutcomeOfCancelledCalls(t *testing.T) {
+	b := New("test", Settings{FailureThreshold: 1, Cooldown: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b.Execute(ctx, fail)
+	assert.Equal(t, Closed, b.State())
+}
@@ -1,21 +1,81 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/svranesevic/charlyedu/cache"
+	"github.com/svranesevic/charlyedu/circuitbreaker"
+	"github.com/svranesevic/charlyedu/daysource"
+	"github.com/svranesevic/charlyedu/rangefetcher"
 	"github.com/svranesevic/charlyedu/router"
+	"github.com/svranesevic/charlyedu/store"
 	"github.com/svranesevic/charlyedu/temperatureservice"
+	"github.com/svranesevic/charlyedu/upstream"
+	"github.com/svranesevic/charlyedu/warmer"
 	"github.com/svranesevic/charlyedu/weatherservice"
 	"github.com/svranesevic/charlyedu/windspeedservice"
 	log "go.uber.org/zap"
 )
 
 type config struct {
-	Port               uint64 `default:"3000"`
-	TemperatureService string `default:"http://localhost:8000/" split_words:"true"`
-	WindSpeedService   string `default:"http://localhost:8080/" split_words:"true"`
+	Port                          uint64 `default:"3000"`
+	DebugAddr                     string `split_words:"true"`
+	TemperatureService            string `default:"http://localhost:8000/" split_words:"true"`
+	TemperatureServiceConcurrency int    `default:"16" split_words:"true"`
+	WindSpeedService              string `default:"http://localhost:8080/" split_words:"true"`
+	WindSpeedServiceConcurrency   int    `default:"16" split_words:"true"`
+	WeatherServiceConcurrency     int    `default:"8" split_words:"true"`
+	RangePolicy                   string `default:"strict" split_words:"true"`
+	ResponseMaxPoints             int    `split_words:"true"`
+
+	StreamTimeout time.Duration `default:"5m" split_words:"true"`
+
+	UpstreamUserAgent           string        `default:"charlyedu" split_words:"true"`
+	UpstreamRequestTimeout      time.Duration `default:"3s" split_words:"true"`
+	UpstreamMaxIdleConns        int           `default:"64" split_words:"true"`
+	UpstreamMaxIdleConnsPerHost int           `default:"32" split_words:"true"`
+
+	UpstreamRetryMaxAttempts     int           `default:"3" split_words:"true"`
+	UpstreamRetryBaseBackoff     time.Duration `default:"100ms" split_words:"true"`
+	UpstreamRetryMaxBackoff      time.Duration `default:"2s" split_words:"true"`
+	UpstreamRetryJitter          float64       `default:"0.5" split_words:"true"`
+	UpstreamRetryableStatusCodes []int         `default:"429,502,503,504" split_words:"true"`
+
+	TemperatureServiceRateLimit float64 `default:"200" split_words:"true"`
+	TemperatureServiceRateBurst int     `default:"50" split_words:"true"`
+	WindSpeedServiceRateLimit   float64 `default:"200" split_words:"true"`
+	WindSpeedServiceRateBurst   int     `default:"50" split_words:"true"`
+
+	UpstreamHedgeDelay      time.Duration `split_words:"true"`
+	UpstreamHedgePercentile float64       `split_words:"true"`
+	UpstreamHedgeMaxRate    float64       `default:"0.05" split_words:"true"`
+
+	UpstreamConcurrencyInitial int           `default:"16" split_words:"true"`
+	UpstreamConcurrencyMin     int           `default:"2" split_words:"true"`
+	UpstreamConcurrencyMax     int           `default:"64" split_words:"true"`
+	UpstreamConcurrencyLatency time.Duration `default:"500ms" split_words:"true"`
+	UpstreamConcurrencyBackoff float64       `default:"0.9" split_words:"true"`
+
+	UpstreamBreakerFailureThreshold int           `default:"5" split_words:"true"`
+	UpstreamBreakerSuccessThreshold int           `default:"2" split_words:"true"`
+	UpstreamBreakerCooldown         time.Duration `default:"15s" split_words:"true"`
+
+	TemperatureCacheSize int           `default:"50000" split_words:"true"`
+	WindSpeedCacheSize   int           `default:"50000" split_words:"true"`
+	CacheTodayTTL        time.Duration `default:"5m" split_words:"true"`
+	StorePath            string        `split_words:"true"`
+
+	WarmerMode     string        `default:"off" split_words:"true"`
+	WarmerInterval time.Duration `default:"200ms" split_words:"true"`
 }
 
 func main() {
@@ -26,14 +86,211 @@ func main() {
 	if err := envconfig.Process("", &c); err != nil {
 		log.S().Fatalf("Unable to process ENV config: %v\n", err.Error())
 	}
+	if c.WarmerMode != "off" && c.WarmerMode != "background" && c.WarmerMode != "oneshot" {
+		log.S().Fatalf("Unable to process ENV config: unknown warmer mode %q, must be one of off, background, oneshot\n", c.WarmerMode)
+	}
+	if c.WarmerMode == "oneshot" && c.StorePath == "" {
+		log.S().Fatalf("Unable to process ENV config: oneshot warmer mode requires a store path\n")
+	}
+
+	if c.StreamTimeout <= 0 {
+		log.S().Fatalf("Unable to process ENV config: stream timeout must be positive\n")
+	}
+
+	policy, err := rangefetcher.ParsePolicy(c.RangePolicy)
+	if err != nil {
+		log.S().Fatalf("Unable to process ENV config: %v\n", err.Error())
+	}
+
+	rangeOpts := []rangefetcher.Option{rangefetcher.WithPolicy(policy), rangefetcher.WithMaxPoints(c.ResponseMaxPoints)}
+
+	retry := upstream.RetryPolicy{
+		MaxAttempts:          c.UpstreamRetryMaxAttempts,
+		BaseBackoff:          c.UpstreamRetryBaseBackoff,
+		MaxBackoff:           c.UpstreamRetryMaxBackoff,
+		Jitter:               c.UpstreamRetryJitter,
+		RetryableStatusCodes: c.UpstreamRetryableStatusCodes,
+	}
 
-	ts := temperatureservice.New(c.TemperatureService)
-	wss := windspeedservice.New(c.WindSpeedService)
-	ws := weatherservice.New(ts, wss)
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        c.UpstreamMaxIdleConns,
+		MaxIdleConnsPerHost: c.UpstreamMaxIdleConnsPerHost,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	hedge := upstream.HedgePolicy{
+		Delay:      c.UpstreamHedgeDelay,
+		Percentile: c.UpstreamHedgePercentile,
+		MaxRate:    c.UpstreamHedgeMaxRate,
+	}
+	if err := hedge.Validate(); err != nil {
+		log.S().Fatalf("Unable to process ENV config: %v\n", err.Error())
+	}
 
-	r := router.New(ts, wss, ws)
+	upstreamOpts := []upstream.Option{
+		upstream.WithTransport(transport),
+		upstream.WithUserAgent(c.UpstreamUserAgent),
+		upstream.WithTimeout(c.UpstreamRequestTimeout),
+		upstream.WithRetryPolicy(retry),
+		upstream.WithHedging(hedge),
+	}
+
+	concurrencySettings := upstream.ConcurrencySettings{
+		Initial: c.UpstreamConcurrencyInitial,
+		Min:     c.UpstreamConcurrencyMin,
+		Max:     c.UpstreamConcurrencyMax,
+		Latency: c.UpstreamConcurrencyLatency,
+		Backoff: c.UpstreamConcurrencyBackoff,
+	}
+
+	breakerSettings := circuitbreaker.Settings{
+		FailureThreshold: c.UpstreamBreakerFailureThreshold,
+		SuccessThreshold: c.UpstreamBreakerSuccessThreshold,
+		Cooldown:         c.UpstreamBreakerCooldown,
+	}
+
+	var st *store.Store
+	if c.StorePath != "" {
+		if st, err = store.Open(c.StorePath); err != nil {
+			log.S().Fatalf("Unable to open store %s: %v\n", c.StorePath, err.Error())
+		}
+	}
 
-	addr := fmt.Sprintf(":%d", c.Port)
-	log.S().Infof("Server starting on %s", addr)
-	log.S().Fatal(http.ListenAndServe(addr, r).Error())
+	tsConcurrency := upstream.NewConcurrencyLimiter(concurrencySettings)
+	expvar.Publish("temperature_concurrency", expvar.Func(func() interface{} { return tsConcurrency.Stats() }))
+	tsUpstream := temperatureservice.New(c.TemperatureService,
+		append(upstreamOpts,
+			upstream.WithRateLimit(c.TemperatureServiceRateLimit, c.TemperatureServiceRateBurst),
+			upstream.WithConcurrencyLimiter(tsConcurrency))...)
+	ts, tsWarm := daysource.WithCircuitBreaker(tsUpstream, circuitbreaker.New("temperature", breakerSettings)), tsUpstream
+	if st != nil {
+		ts, tsWarm = temperatureservice.WithStore(ts, st), temperatureservice.WithStore(tsWarm, st)
+	}
+	if c.TemperatureCacheSize > 0 {
+		tsCache := cache.NewLRU(c.TemperatureCacheSize)
+		expvar.Publish("temperature_cache", expvar.Func(func() interface{} { return tsCache.Stats() }))
+		ts, tsWarm = daysource.WithCache(ts, tsCache, c.CacheTodayTTL), daysource.WithCache(tsWarm, tsCache, c.CacheTodayTTL)
+	}
+
+	wssConcurrency := upstream.NewConcurrencyLimiter(concurrencySettings)
+	expvar.Publish("wind_speed_concurrency", expvar.Func(func() interface{} { return wssConcurrency.Stats() }))
+	wssUpstream := windspeedservice.New(c.WindSpeedService,
+		append(upstreamOpts,
+			upstream.WithRateLimit(c.WindSpeedServiceRateLimit, c.WindSpeedServiceRateBurst),
+			upstream.WithConcurrencyLimiter(wssConcurrency))...)
+	wss, wssWarm := daysource.WithCircuitBreaker(wssUpstream, circuitbreaker.New("wind speed", breakerSettings)), wssUpstream
+	if st != nil {
+		wss, wssWarm = windspeedservice.WithStore(wss, st), windspeedservice.WithStore(wssWarm, st)
+	}
+	if c.WindSpeedCacheSize > 0 {
+		wssCache := cache.NewLRU(c.WindSpeedCacheSize)
+		expvar.Publish("wind_speed_cache", expvar.Func(func() interface{} { return wssCache.Stats() }))
+		wss, wssWarm = daysource.WithCache(wss, wssCache, c.CacheTodayTTL), daysource.WithCache(wssWarm, wssCache, c.CacheTodayTTL)
+	}
+
+	tsService := temperatureservice.NewService(ts, rangefetcher.New("temperature", c.TemperatureServiceConcurrency, rangeOpts...))
+	wssService := windspeedservice.NewService(wss, rangefetcher.New("wind speed", c.WindSpeedServiceConcurrency, rangeOpts...))
+	ws := weatherservice.New(tsService, wssService, rangefetcher.New("weather", c.WeatherServiceConcurrency, rangeOpts...))
+
+	// The warmers share the store and cache of requests but bypass their
+	// circuit breakers, so that backfilling from a slow backing service does
+	// not trip them for requests.
+	warmers := []*warmer.Warmer{
+		warmer.New("temperature", func(ctx context.Context, at time.Time) error {
+			_, err := tsWarm.GetForDateTime(ctx, at)
+			return err
+		}, c.WarmerInterval, warmerCheckpoint(st, "temperature")),
+		warmer.New("wind speed", func(ctx context.Context, at time.Time) error {
+			_, err := wssWarm.GetForDateTime(ctx, at)
+			return err
+		}, c.WarmerInterval, warmerCheckpoint(st, "wind speed")),
+	}
+
+	warmCtx, stopWarming := context.WithCancel(context.Background())
+	defer stopWarming()
+	var warming sync.WaitGroup
+
+	switch c.WarmerMode {
+	case "background":
+		for _, w := range warmers {
+			warming.Add(1)
+			go func(w *warmer.Warmer) {
+				defer warming.Done()
+
+				if err := w.Run(warmCtx); err != nil && err != context.Canceled {
+					log.S().Errorf("Warmer stopped: %+v", err)
+				}
+			}(w)
+		}
+	case "oneshot":
+		backfill(warmers)
+		st.Close()
+		return
+	}
+
+	r := router.New(tsService, wssService, ws, c.StreamTimeout)
+
+	if c.DebugAddr != "" {
+		// Metrics are kept off the public router, on a listener which should
+		// only be reachable by operators.
+		debug := http.NewServeMux()
+		debug.Handle("/debug/vars", expvar.Handler())
+		go func() {
+			log.S().Infof("Debug server starting on %s", c.DebugAddr)
+			log.S().Fatal(http.ListenAndServe(c.DebugAddr, debug).Error())
+		}()
+	}
+
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", c.Port), Handler: r}
+	go func() {
+		log.S().Infof("Server starting on %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			log.S().Fatal(err.Error())
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	log.S().Info("Server shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.S().Errorf("Unable to shut down gracefully: %v", err)
+	}
+
+	stopWarming()
+	warming.Wait()
+	if st != nil {
+		if err := st.Close(); err != nil {
+			log.S().Errorf("Unable to close store %s: %v", c.StorePath, err)
+		}
+	}
+}
+
+// backfill runs a single backfill of every warmer concurrently and waits for
+// them to finish.
+func backfill(warmers []*warmer.Warmer) {
+	var wg sync.WaitGroup
+	for _, w := range warmers {
+		wg.Add(1)
+		go func(w *warmer.Warmer) {
+			defer wg.Done()
+
+			if err := w.Backfill(context.Background()); err != nil {
+				log.S().Errorf("Backfill failed: %+v", err)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	log.S().Info("Backfill finished")
+}
+
+func warmerCheckpoint(st *store.Store, name string) warmer.Checkpoint {
+	if st == nil {
+		return nil
+	}
+	return st.Checkpoint("warmer " + name)
 }
@@ -0,0 +1,36 @@
+package daysource
+
+import (
+	"context"
+	"time"
+
+	"github.com/svranesevic/charlyedu/cache"
+)
+
+type cachingSource struct {
+	next     Source
+	cache    *cache.LRU
+	todayTTL time.Duration
+}
+
+// WithCache keeps the readings obtained through s in c. Readings of past days
+// never change and stay cached until evicted, while today's reading is only
+// cached for todayTTL.
+func WithCache(s Source, c *cache.LRU, todayTTL time.Duration) Source {
+	return cachingSource{next: s, cache: c, todayTTL: todayTTL}
+}
+
+func (s cachingSource) GetForDateTime(ctx context.Context, at time.Time) (interface{}, error) {
+	key := cache.DayKey(at)
+	if r, ok := s.cache.Get(key); ok {
+		return r, nil
+	}
+
+	r, err := s.next.GetForDateTime(ctx, at)
+	if err != nil || r == nil {
+		return r, err
+	}
+
+	s.cache.Add(key, r, cache.DayTTL(at, s.todayTTL))
+	return r, nil
+}
@@ -0,0 +1,53 @@
+package daysource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/cache"
+)
+
+func TestCacheServesRepeatedDaysFromCache(t *testing.T) {
+	src := &countingSource{}
+	c := cache.NewLRU(10)
+	s := WithCache(src, c, time.Minute)
+
+	first, err := s.GetForDateTime(context.Background(), pastDay)
+	assert.Nil(t, err)
+	second, err := s.GetForDateTime(context.Background(), pastDay)
+	assert.Nil(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, int32(1), src.count())
+	assert.Equal(t, int64(1), c.Stats().Hits)
+	assert.Equal(t, int64(1), c.Stats().Misses)
+}
+
+func TestCacheExpiresTodayAfterTodayTTL(t *testing.T) {
+	src := &countingSource{}
+	s := WithCache(src, cache.NewLRU(10), 20*time.Millisecond)
+	today := time.Now().UTC()
+
+	s.GetForDateTime(context.Background(), today)
+	s.GetForDateTime(context.Background(), today)
+	assert.Equal(t, int32(1), src.count())
+
+	time.Sleep(30 * time.Millisecond)
+	s.GetForDateTime(context.Background(), today)
+	assert.Equal(t, int32(2), src.count())
+}
+
+func TestCacheDoesNotCacheErrors(t *testing.T) {
+	src := &countingSource{err: errors.New("upstream error")}
+	s := WithCache(src, cache.NewLRU(10), time.Minute)
+
+	_, err := s.GetForDateTime(context.Background(), pastDay)
+	assert.NotNil(t, err)
+	_, err = s.GetForDateTime(context.Background(), pastDay)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, int32(2), src.count())
+}
@@ -0,0 +1,29 @@
+package daysource
+
+import (
+	"context"
+	"time"
+
+	"github.com/svranesevic/charlyedu/circuitbreaker"
+)
+
+type circuitBreakingSource struct {
+	next    Source
+	breaker *circuitbreaker.Breaker
+}
+
+// WithCircuitBreaker guards s with breaker, so that while the backing service
+// is known to be down calls fail fast with circuitbreaker.ErrOpen.
+func WithCircuitBreaker(s Source, breaker *circuitbreaker.Breaker) Source {
+	return circuitBreakingSource{next: s, breaker: breaker}
+}
+
+func (s circuitBreakingSource) GetForDateTime(ctx context.Context, at time.Time) (interface{}, error) {
+	var r interface{}
+	err := s.breaker.Execute(ctx, func() (err error) {
+		r, err = s.next.GetForDateTime(ctx, at)
+		return err
+	})
+
+	return r, err
+}
@@ -0,0 +1,39 @@
+package daysource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/circuitbreaker"
+	"github.com/svranesevic/charlyedu/rangefetcher"
+)
+
+func TestCircuitBreakerFailsFastOnceOpen(t *testing.T) {
+	src := &countingSource{err: errors.New("upstream error")}
+	s := WithCircuitBreaker(src, circuitbreaker.New("test", circuitbreaker.Settings{FailureThreshold: 1, Cooldown: time.Minute}))
+
+	_, err := s.GetForDateTime(context.Background(), pastDay)
+	assert.Equal(t, src.err, err)
+	_, err = s.GetForDateTime(context.Background(), pastDay)
+	assert.Equal(t, circuitbreaker.ErrOpen, err)
+
+	assert.Equal(t, int32(1), src.count())
+}
+
+func TestCircuitBreakerFailsRangeAsWholeWhileHalfOpen(t *testing.T) {
+	breaker := circuitbreaker.New("test", circuitbreaker.Settings{FailureThreshold: 1, SuccessThreshold: 1, Cooldown: time.Millisecond})
+	breaker.Execute(context.Background(), func() error { return errors.New("upstream error") })
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, circuitbreaker.HalfOpen, breaker.State())
+
+	src := &countingSource{delay: 20 * time.Millisecond}
+	s := WithCircuitBreaker(src, breaker)
+	_, err := rangefetcher.New("test", 4, rangefetcher.WithPolicy(rangefetcher.Partial)).Fetch(context.Background(), pastDay, pastDay.AddDate(0, 0, 30), s.GetForDateTime)
+
+	assert.Equal(t, circuitbreaker.ErrOpen, err)
+	// The probe let through still closes the breaker.
+	assert.Equal(t, circuitbreaker.Closed, breaker.State())
+}
@@ -0,0 +1,47 @@
+package daysource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/svranesevic/charlyedu/serviceerror"
+	"github.com/svranesevic/charlyedu/upstream"
+)
+
+// Source obtains the reading of a single day, whichever metric it is of. A
+// nil reading with a nil error means the day is not available. Readings are
+// shared between callers, so they are values which must not be modified.
+// Sources are decorated with caching, persistence and circuit breaking, and
+// the metric services serve their readings out of the outermost one.
+type Source interface {
+	GetForDateTime(ctx context.Context, at time.Time) (interface{}, error)
+}
+
+// DecodeFunc decodes the JSON payload of a reading.
+type DecodeFunc func(payload []byte) (interface{}, error)
+
+type upstreamSource struct {
+	client *upstream.Client
+	decode DecodeFunc
+}
+
+// Upstream returns a Source obtaining readings from client, decoding them
+// with decode.
+func Upstream(client *upstream.Client, decode DecodeFunc) Source {
+	return upstreamSource{client: client, decode: decode}
+}
+
+func (s upstreamSource) GetForDateTime(ctx context.Context, at time.Time) (interface{}, error) {
+	var payload json.RawMessage
+	if found, err := s.client.Get(ctx, at, &payload); err != nil || !found {
+		return nil, err
+	}
+
+	r, err := s.decode(payload)
+	if err != nil {
+		return nil, serviceerror.Wrap(serviceerror.MalformedPayload, err, fmt.Sprintf("malformed payload for datetime %s", at))
+	}
+	return r, nil
+}
@@ -0,0 +1,79 @@
+package daysource
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/serviceerror"
+	"github.com/svranesevic/charlyedu/upstream"
+)
+
+type reading struct {
+	Value float64   `json:"value"`
+	Date  time.Time `json:"date"`
+}
+
+func decodeReading(payload []byte) (interface{}, error) {
+	var r reading
+	err := json.Unmarshal(payload, &r)
+	return r, err
+}
+
+var pastDay = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// countingSource counts the readings obtained through it, which are available
+// for every day after delay unless it fails with err.
+type countingSource struct {
+	calls int32
+	delay time.Duration
+	err   error
+}
+
+func (s *countingSource) GetForDateTime(ctx context.Context, at time.Time) (interface{}, error) {
+	atomic.AddInt32(&s.calls, 1)
+	time.Sleep(s.delay)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return reading{Value: 1.5, Date: at}, nil
+}
+
+func (s *countingSource) count() int32 {
+	return atomic.LoadInt32(&s.calls)
+}
+
+func TestUpstreamDecodesReading(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"value":10.5,"date":"2019-01-01T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	r, err := Upstream(upstream.New(server.URL), decodeReading).GetForDateTime(context.Background(), pastDay)
+	assert.Nil(t, err)
+	assert.Equal(t, reading{Value: 10.5, Date: pastDay}, r)
+}
+
+func TestUpstreamReturnsNoReadingOn404(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	r, err := Upstream(upstream.New(server.URL), decodeReading).GetForDateTime(context.Background(), pastDay)
+	assert.Nil(t, err)
+	assert.Nil(t, r)
+}
+
+func TestUpstreamReturnsMalformedPayloadErrorOnUndecodableReading(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"value":"warm"}`))
+	}))
+	defer server.Close()
+
+	_, err := Upstream(upstream.New(server.URL), decodeReading).GetForDateTime(context.Background(), pastDay)
+	assert.Equal(t, serviceerror.MalformedPayload, serviceerror.KindOf(err))
+}
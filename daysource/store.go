@@ -0,0 +1,51 @@
+package daysource
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/svranesevic/charlyedu/cache"
+	"github.com/svranesevic/charlyedu/store"
+	log "go.uber.org/zap"
+)
+
+type storingSource struct {
+	next   Source
+	store  *store.Store
+	bucket string
+	decode DecodeFunc
+}
+
+// WithStore looks readings up in bucket of st before obtaining them through
+// s, decoding them with decode, and persists the readings of past days
+// obtained through s in it.
+func WithStore(s Source, st *store.Store, bucket string, decode DecodeFunc) Source {
+	return storingSource{next: s, store: st, bucket: bucket, decode: decode}
+}
+
+func (s storingSource) GetForDateTime(ctx context.Context, at time.Time) (interface{}, error) {
+	if r, err := s.get(at); err != nil {
+		log.S().Errorf("failed to read stored %s for datetime %s, %+v", s.bucket, at, err)
+	} else if r != nil {
+		return r, nil
+	}
+
+	r, err := s.next.GetForDateTime(ctx, at)
+	if err != nil || r == nil || !cache.Final(at) {
+		return r, err
+	}
+
+	if err := s.store.Put(s.bucket, at, r); err != nil {
+		log.S().Errorf("failed to store %s for datetime %s, %+v", s.bucket, at, err)
+	}
+	return r, nil
+}
+
+func (s storingSource) get(at time.Time) (interface{}, error) {
+	var payload json.RawMessage
+	if found, err := s.store.Get(s.bucket, at, &payload); err != nil || !found {
+		return nil, err
+	}
+	return s.decode(payload)
+}
@@ -0,0 +1,67 @@
+package daysource
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/store"
+)
+
+func openTestStore(t *testing.T) (*store.Store, func()) {
+	dir, err := ioutil.TempDir("", "daysource")
+	assert.Nil(t, err)
+
+	st, err := store.Open(filepath.Join(dir, "readings.db"))
+	assert.Nil(t, err)
+
+	return st, func() {
+		st.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestStorePersistsPastDays(t *testing.T) {
+	st, cleanup := openTestStore(t)
+	defer cleanup()
+
+	r, err := WithStore(&countingSource{}, st, "reading", decodeReading).GetForDateTime(context.Background(), pastDay)
+	assert.Nil(t, err)
+
+	src := &countingSource{err: errors.New("upstream error")}
+	stored, err := WithStore(src, st, "reading", decodeReading).GetForDateTime(context.Background(), pastDay)
+	assert.Nil(t, err)
+	assert.Equal(t, r, stored)
+	assert.Equal(t, int32(0), src.count())
+}
+
+func TestStoreDoesNotPersistToday(t *testing.T) {
+	st, cleanup := openTestStore(t)
+	defer cleanup()
+	today := time.Now().UTC()
+
+	src := &countingSource{}
+	s := WithStore(src, st, "reading", decodeReading)
+	s.GetForDateTime(context.Background(), today)
+	s.GetForDateTime(context.Background(), today)
+
+	assert.Equal(t, int32(2), src.count())
+}
+
+func TestStoreDoesNotPersistErrors(t *testing.T) {
+	st, cleanup := openTestStore(t)
+	defer cleanup()
+
+	_, err := WithStore(&countingSource{err: errors.New("upstream error")}, st, "reading", decodeReading).GetForDateTime(context.Background(), pastDay)
+	assert.NotNil(t, err)
+
+	src := &countingSource{}
+	_, err = WithStore(src, st, "reading", decodeReading).GetForDateTime(context.Background(), pastDay)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), src.count())
+}
@@ -1,13 +1,73 @@
 package handler
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/svranesevic/charlyedu/serviceerror"
+	log "go.uber.org/zap"
+)
+
+// Error codes are part of the API: clients branch on them, so they must not
+// change once published.
+const (
+	CodeInvalidParameter    = "invalid_parameter"
+	CodeNotAcceptable       = "not_acceptable"
+	CodeInvalidRange        = "invalid_range"
+	CodeOutOfBounds         = "out_of_bounds"
+	CodeNotFound            = "not_found"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeUpstreamTimeout     = "upstream_timeout"
+	CodeMalformedUpstream   = "malformed_upstream_payload"
+	CodeInternal            = "internal_error"
+)
 
 type errorResponse struct {
+	Code        string `json:"code"`
 	Description string `json:"message"`
 }
 
-func NewErrorResponse(description string) string {
-	err := errorResponse{Description: description}
+type errorMapping struct {
+	status int
+	code   string
+}
+
+var errorMappings = map[serviceerror.Kind]errorMapping{
+	serviceerror.InvalidRange:     {http.StatusBadRequest, CodeInvalidRange},
+	serviceerror.NotFound:         {http.StatusNotFound, CodeNotFound},
+	serviceerror.OutOfBounds:      {http.StatusUnprocessableEntity, CodeOutOfBounds},
+	serviceerror.MalformedPayload: {http.StatusBadGateway, CodeMalformedUpstream},
+	serviceerror.Unavailable:      {http.StatusServiceUnavailable, CodeUpstreamUnavailable},
+	serviceerror.Timeout:          {http.StatusGatewayTimeout, CodeUpstreamTimeout},
+}
+
+func NewErrorResponse(code string, description string) string {
+	err := errorResponse{Code: code, Description: description}
 	b, _ := json.Marshal(err)
 	return string(b)
 }
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, code string, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(NewErrorResponse(code, description) + "\n"))
+}
+
+// writeServiceError writes the JSON error response for an error returned by
+// one of the services, with a status code depending on its kind.
+func writeServiceError(w http.ResponseWriter, err error) {
+	mapping := errorMappingFor(err)
+	if mapping.code == CodeInternal {
+		log.S().Errorf("Failed to serve request: %+v", err)
+	}
+
+	writeError(w, mapping.status, mapping.code, err.Error())
+}
+
+func errorMappingFor(err error) errorMapping {
+	if mapping, ok := errorMappings[serviceerror.KindOf(err)]; ok {
+		return mapping
+	}
+	return errorMapping{http.StatusInternalServerError, CodeInternal}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/serviceerror"
+)
+
+func TestWriteServiceErrorMapsKindsToStatusCodes(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{serviceerror.ErrInvalidRange, http.StatusBadRequest, CodeInvalidRange},
+		{serviceerror.ErrNotFound, http.StatusNotFound, CodeNotFound},
+		{serviceerror.ErrOutOfBounds, http.StatusUnprocessableEntity, CodeOutOfBounds},
+		{serviceerror.ErrMalformedPayload, http.StatusBadGateway, CodeMalformedUpstream},
+		{serviceerror.ErrUnavailable, http.StatusServiceUnavailable, CodeUpstreamUnavailable},
+		{serviceerror.ErrTimeout, http.StatusGatewayTimeout, CodeUpstreamTimeout},
+		{errors.New("unknown"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		writeServiceError(rec, c.err)
+
+		assert.Equal(t, c.status, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var res errorResponse
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		assert.Equal(t, errorResponse{Code: c.code, Description: c.err.Error()}, res)
+	}
+}
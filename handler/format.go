@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// format is a representation range responses can be written in.
+type format struct {
+	name        string
+	contentType string
+	// newWriter returns a writer of the readings of a range, each of them
+	// a JSON object. It is nil for JSON itself, which is written whole.
+	newWriter func(w io.Writer) recordWriter
+}
+
+// recordWriter writes the readings of a range one at a time.
+type recordWriter interface {
+	write(record json.RawMessage) error
+	flush() error
+}
+
+var (
+	jsonFormat   = format{name: "json", contentType: "application/json"}
+	ndjsonFormat = format{name: "ndjson", contentType: "application/x-ndjson", newWriter: newNDJSONWriter}
+	csvFormat    = format{name: "csv", contentType: "text/csv; charset=utf-8", newWriter: delimitedWriterFactory(',')}
+	tsvFormat    = format{name: "tsv", contentType: "text/tab-separated-values; charset=utf-8", newWriter: delimitedWriterFactory('\t')}
+)
+
+var formats = []format{jsonFormat, ndjsonFormat, csvFormat, tsvFormat}
+
+// mediaTypes maps the media types clients may accept to the format served
+// for them.
+var mediaTypes = map[string]format{
+	"*/*":                       jsonFormat,
+	"application/*":             jsonFormat,
+	"application/json":          jsonFormat,
+	"application/x-ndjson":      ndjsonFormat,
+	"application/ndjson":        ndjsonFormat,
+	"text/csv":                  csvFormat,
+	"text/tab-separated-values": tsvFormat,
+}
+
+// negotiateFormat picks the format to respond in: the one named by the
+// `format` query parameter if there is one, the most preferred one the Accept
+// header allows otherwise, and JSON if neither is given.
+func negotiateFormat(r *http.Request) (format, error) {
+	if name := r.FormValue("format"); name != "" {
+		for _, f := range formats {
+			if strings.EqualFold(f.name, name) {
+				return f, nil
+			}
+		}
+		return jsonFormat, fmt.Errorf("unsupported format %q, must be one of json, ndjson, csv, tsv", name)
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return jsonFormat, nil
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	ranges := make([]mediaRange, 0)
+	for _, s := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(s)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if qStr, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qStr, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, mr := range ranges {
+		if f, ok := mediaTypes[mr.mediaType]; ok {
+			return f, nil
+		}
+	}
+	return jsonFormat, fmt.Errorf("none of the accepted media types %q is supported, must be one of application/json, application/x-ndjson, text/csv, text/tab-separated-values", accept)
+}
+
+type ndjsonWriter struct {
+	w io.Writer
+}
+
+func newNDJSONWriter(w io.Writer) recordWriter {
+	return &ndjsonWriter{w: w}
+}
+
+func (nw *ndjsonWriter) write(record json.RawMessage) error {
+	if _, err := nw.w.Write(record); err != nil {
+		return err
+	}
+	_, err := nw.w.Write([]byte{'\n'})
+	return err
+}
+
+func (nw *ndjsonWriter) flush() error {
+	return nil
+}
+
+// delimitedWriter writes records as rows of delimiter separated values. The
+// header names the columns after the JSON fields of the first record, joining
+// the names of nested fields with dots. Every later record must have the same
+// columns, a record which does not fails the write rather than having the
+// columns the header lacks dropped.
+type delimitedWriter struct {
+	cw     *csv.Writer
+	header []string
+}
+
+func delimitedWriterFactory(delimiter rune) func(w io.Writer) recordWriter {
+	return func(w io.Writer) recordWriter {
+		cw := csv.NewWriter(w)
+		cw.Comma = delimiter
+		return &delimitedWriter{cw: cw}
+	}
+}
+
+func (dw *delimitedWriter) write(record json.RawMessage) error {
+	cols, err := flatten("", record, make([]column, 0))
+	if err != nil {
+		return err
+	}
+
+	if dw.header == nil {
+		dw.header = make([]string, len(cols))
+		for i, col := range cols {
+			dw.header[i] = col.name
+		}
+		if err := dw.cw.Write(dw.header); err != nil {
+			return err
+		}
+	}
+
+	if len(cols) != len(dw.header) {
+		return fmt.Errorf("record has %d columns, header has %d", len(cols), len(dw.header))
+	}
+	row := make([]string, len(cols))
+	for i, col := range cols {
+		if col.name != dw.header[i] {
+			return fmt.Errorf("record has column %q where header has %q", col.name, dw.header[i])
+		}
+		row[i] = col.value
+	}
+	return dw.cw.Write(row)
+}
+
+func (dw *delimitedWriter) flush() error {
+	dw.cw.Flush()
+	return dw.cw.Error()
+}
+
+type column struct {
+	name  string
+	value string
+}
+
+// flatten appends the fields of the JSON object raw to cols, in the order
+// they appear in, prefixing their names with prefix.
+func flatten(prefix string, raw json.RawMessage, cols []column) ([]column, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name := prefix + token.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		switch value[0] {
+		case '{':
+			if cols, err = flatten(name+".", value, cols); err != nil {
+				return nil, err
+			}
+			continue
+		case '"':
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				return nil, err
+			}
+			cols = append(cols, column{name: name, value: s})
+		case 'n':
+			cols = append(cols, column{name: name})
+		default:
+			cols = append(cols, column{name: name, value: string(value)})
+		}
+	}
+
+	return cols, nil
+}
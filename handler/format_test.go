@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/temperatureservice"
+	"github.com/svranesevic/charlyedu/weatherservice"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		accept string
+		query  string
+		format string
+	}{
+		{accept: "", format: "json"},
+		{accept: "*/*", format: "json"},
+		{accept: "text/csv", format: "csv"},
+		{accept: "text/csv;q=0.5, application/x-ndjson", format: "ndjson"},
+		{accept: "image/png, text/tab-separated-values;q=0.1", format: "tsv"},
+		{accept: "text/csv", query: "format=json", format: "json"},
+		{query: "format=TSV", format: "tsv"},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?"+c.query, nil)
+		assert.Nil(t, err)
+		req.Header.Set("Accept", c.accept)
+
+		f, err := negotiateFormat(req)
+		assert.Nil(t, err, c.accept)
+		assert.Equal(t, c.format, f.name, c.accept)
+	}
+}
+
+func TestNegotiateFormatServesTextWildcardNothingByDefault(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures", nil)
+	assert.Nil(t, err)
+
+	req.Header.Set("Accept", "text/*")
+	_, err = negotiateFormat(req)
+	assert.NotNil(t, err)
+
+	req.Header.Set("Accept", "text/*, application/json;q=0.5")
+	f, err := negotiateFormat(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "json", f.name)
+}
+
+func TestGetTemperatureReturnsNotAcceptableErrorOnUnsupportedMediaType(t *testing.T) {
+	tempService := temperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z", nil)
+	assert.Nil(t, err)
+	req.Header.Set("Accept", "application/xml, text/csv;q=0")
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var res errorResponse
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, CodeNotAcceptable, res.Code)
+}
+
+func TestGetTemperatureWritesCSV(t *testing.T) {
+	tempService := temperatureServiceStub{
+		Temperatures: []temperatureservice.Temperature{
+			{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Temperature: 1.5},
+			{Date: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), Temperature: -2},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-02T00:00:00Z", nil)
+	assert.Nil(t, err)
+	req.Header.Set("Accept", "text/csv")
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "temp,date\n1.5,2019-01-01T00:00:00Z\n-2,2019-01-02T00:00:00Z\n", rec.Body.String())
+}
+
+func TestGetWeatherWritesNestedAndNullFieldsAsTSVColumns(t *testing.T) {
+	weatherService := weatherServiceStub{
+		Weathers: []weatherservice.Weather{
+			{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Temperature: 20, North: 1},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/weather?start=2019-01-01T00:00:00Z&end=2019-01-01T00:00:00Z&comfort=true&format=tsv", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetWeather(weatherService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "north\twest\ttemp\tdate\twind_chill\tapparent_temperature\n1\t0\t20\t2019-01-01T00:00:00Z\t\t20\n", rec.Body.String())
+	assert.NotEmpty(t, rec.Header().Get("X-Meta"))
+}
+
+func TestDelimitedWriterFailsOnRecordWithOtherColumns(t *testing.T) {
+	var buf bytes.Buffer
+	w := csvFormat.newWriter(&buf)
+
+	assert.Nil(t, w.write(json.RawMessage(`{"temp":1,"date":"2019-01-01T00:00:00Z"}`)))
+	assert.NotNil(t, w.write(json.RawMessage(`{"temp":2,"date":"2019-01-02T00:00:00Z","north":3}`)))
+	assert.NotNil(t, w.write(json.RawMessage(`{"date":"2019-01-03T00:00:00Z","temp":4}`)))
+	assert.Nil(t, w.write(json.RawMessage(`{"temp":5,"date":"2019-01-05T00:00:00Z"}`)))
+	assert.Nil(t, w.flush())
+
+	assert.Equal(t, "temp,date\n1,2019-01-01T00:00:00Z\n5,2019-01-05T00:00:00Z\n", buf.String())
+}
+
+func TestGetTemperatureWritesNDJSON(t *testing.T) {
+	tempService := temperatureServiceStub{
+		Temperatures: []temperatureservice.Temperature{
+			{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Temperature: 1.5},
+			{Date: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), Temperature: -2},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-02T00:00:00Z&format=ndjson", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"temp\":1.5,\"date\":\"2019-01-01T00:00:00Z\"}\n{\"temp\":-2,\"date\":\"2019-01-02T00:00:00Z\"}\n", rec.Body.String())
+}
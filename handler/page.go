@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/svranesevic/charlyedu/rangefetcher"
+)
+
+const defaultPageLimit = 100
+
+// cursorDigestSize is how many bytes of a range's digest its cursors carry.
+const cursorDigestSize = 8
+
+// parsePage pages the range of params as requested through the `limit` and
+// `cursor` query parameters, if either of them is given. If they are invalid
+// it writes a Bad Request response and returns false.
+func parsePage(w http.ResponseWriter, r *http.Request, params *rangeRequest) bool {
+	limitStr, cursor := r.FormValue("limit"), r.FormValue("cursor")
+	if limitStr == "" && cursor == "" {
+		return true
+	}
+
+	limit := defaultPageLimit
+	if limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 {
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, "`limit` must be a positive integer")
+			return false
+		}
+	}
+
+	params.rangeDigest = digestRange(r, *params)
+
+	offset := 0
+	if cursor != "" {
+		var err error
+		if offset, err = decodeCursor(cursor, params.rangeDigest); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("`cursor` is invalid, %v", err))
+			return false
+		}
+	}
+
+	params.opts = append(params.opts, rangefetcher.WithPage(offset, limit))
+	params.pageURL = r.URL
+	return true
+}
+
+// digestRange returns a digest of the range requested, normalized so that
+// equivalent requests of it have the same digest.
+func digestRange(r *http.Request, params rangeRequest) string {
+	step := rangefetcher.Daily
+	if s, _ := samplingStep(r); s != "" {
+		step, _ = rangefetcher.ParseInterval(s)
+	}
+	clamp, _ := parseBool(r, "clamp")
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s|%+v|%t", params.start.Format(time.RFC3339Nano), params.end.Format(time.RFC3339Nano), params.start.Location(), step, clamp)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:cursorDigestSize])
+}
+
+// encodeCursor returns the cursor to the page of the range with the given
+// digest which starts at its offset-th point. Cursors are opaque to clients
+// so that what they hold can change.
+func encodeCursor(offset int, digest string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset) + "." + digest))
+}
+
+// decodeCursor returns the offset a cursor points to, as long as it was
+// returned for the range with the given digest.
+func decodeCursor(cursor string, digest string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.New("it was not returned by a previous response")
+	}
+
+	parts := strings.SplitN(string(b), ".", 2)
+	offset, err := strconv.Atoi(parts[0])
+	if err != nil || offset < 0 || len(parts) != 2 {
+		return 0, errors.New("it was not returned by a previous response")
+	}
+	if parts[1] != digest {
+		return 0, errors.New("it was returned for another range")
+	}
+	return offset, nil
+}
+
+// nextCursor returns the cursor to the page following the one fetched, empty
+// if there is none.
+func nextCursor(params rangeRequest, report rangefetcher.Report) string {
+	if report.Next == 0 {
+		return ""
+	}
+	return encodeCursor(report.Next, params.rangeDigest)
+}
+
+// setLinkHeader links to the page following the one fetched, if there is
+// one.
+func setLinkHeader(w http.ResponseWriter, params rangeRequest, report rangefetcher.Report) {
+	cursor := nextCursor(params, report)
+	if params.pageURL == nil || cursor == "" {
+		return
+	}
+
+	next := *params.pageURL
+	query := next.Query()
+	query.Set("cursor", cursor)
+	next.RawQuery = query.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+}
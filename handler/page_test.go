@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	"github.com/svranesevic/charlyedu/temperatureservice"
+)
+
+// completeTemperatureServiceStub has readings for every day of a range.
+type completeTemperatureServiceStub struct {
+}
+
+func (s completeTemperatureServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]temperatureservice.Temperature, error) {
+	results, err := rangefetcher.New("temperature", 1).Fetch(ctx, from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		return temperatureservice.Temperature{Date: at, Temperature: float64(at.Day())}, nil
+	}, opts...)
+
+	temps := make([]temperatureservice.Temperature, len(results))
+	for i, r := range results {
+		temps[i] = r.(temperatureservice.Temperature)
+	}
+	return temps, err
+}
+
+func (s completeTemperatureServiceStub) GetForDateTime(ctx context.Context, at time.Time) (*temperatureservice.Temperature, error) {
+	return &temperatureservice.Temperature{Date: at, Temperature: float64(at.Day())}, nil
+}
+
+type temperaturePage struct {
+	Data []temperatureservice.Temperature `json:"data"`
+	Next string                           `json:"next"`
+}
+
+func getTemperaturePage(t *testing.T, url string) (temperaturePage, *httptest.ResponseRecorder) {
+	req, err := http.NewRequest("GET", url, nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(completeTemperatureServiceStub{}, rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var page temperaturePage
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	return page, rec
+}
+
+func TestGetTemperaturePagesThroughRange(t *testing.T) {
+	const url = "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-05T00:00:00Z&limit=2&envelope=true"
+
+	first, rec := getTemperaturePage(t, url)
+	assert.Len(t, first.Data, 2)
+	assert.Equal(t, 1.0, first.Data[0].Temperature)
+	assert.NotEmpty(t, first.Next)
+	assert.Equal(t, `</temperatures?cursor=`+first.Next+`&end=2019-01-05T00%3A00%3A00Z&envelope=true&limit=2&start=2019-01-01T00%3A00%3A00Z>; rel="next"`, rec.Header().Get("Link"))
+
+	second, _ := getTemperaturePage(t, url+"&cursor="+first.Next)
+	assert.Len(t, second.Data, 2)
+	assert.Equal(t, 3.0, second.Data[0].Temperature)
+
+	last, rec := getTemperaturePage(t, url+"&cursor="+second.Next)
+	assert.Len(t, last.Data, 1)
+	assert.Equal(t, 5.0, last.Data[0].Temperature)
+	assert.Empty(t, last.Next)
+	assert.Empty(t, rec.Header().Get("Link"))
+}
+
+func TestGetTemperatureReturnsBadRequestErrorOnCursorOfAnotherRange(t *testing.T) {
+	first, _ := getTemperaturePage(t, "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-05T00:00:00Z&limit=2&envelope=true")
+
+	for _, query := range []string{
+		"start=2019-01-02T00:00:00Z&end=2019-01-05T00:00:00Z",
+		"start=2019-01-01T00:00:00Z&end=2019-01-06T00:00:00Z",
+		"start=2019-01-01T00:00:00Z&end=2019-01-05T00:00:00Z&interval=2d",
+		"start=2019-01-01T00:00:00Z&end=2019-01-05T00:00:00Z&tz=Europe/Berlin",
+	} {
+		req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?limit=2&cursor="+first.Next+"&"+query, nil)
+		assert.Nil(t, err)
+
+		rec := httptest.NewRecorder()
+		GetTemperature(completeTemperatureServiceStub{}, rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestGetTemperatureReturnsBadRequestErrorOnInvalidPage(t *testing.T) {
+	for _, query := range []string{"limit=0", "limit=ten", "cursor=bm90LWEtY3Vyc29y", "cursor=!!!"} {
+		req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-05T00:00:00Z&"+query, nil)
+		assert.Nil(t, err)
+
+		rec := httptest.NewRecorder()
+		GetTemperature(completeTemperatureServiceStub{}, rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
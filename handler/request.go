@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/svranesevic/charlyedu/iso8601"
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	"github.com/svranesevic/charlyedu/units"
+)
+
+// rangeRequest is a range requested through query parameters.
+type rangeRequest struct {
+	start  time.Time
+	end    time.Time
+	opts   []rangefetcher.Option
+	units  units.Units
+	format format
+	// meta is the metadata to respond with, to which handlers may add.
+	meta map[string]interface{}
+	// pageURL is the URL of the requested page if the range is paged.
+	pageURL *url.URL
+	// rangeDigest identifies the range if it is paged, so that cursors are
+	// only accepted for the range they were returned for.
+	rangeDigest string
+	// envelope is whether a JSON response is wrapped in a rangeResponse
+	// rather than being a bare array of readings.
+	envelope bool
+}
+
+// parseRangeRequest parses the range requested through query parameters. If
+// they are invalid it writes a Bad Request response and returns false.
+func parseRangeRequest(w http.ResponseWriter, r *http.Request) (rangeRequest, bool) {
+	loc, err := parseZone(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return rangeRequest{}, false
+	}
+
+	start, end, err := parseRange(r, loc)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return rangeRequest{}, false
+	}
+	if loc != nil {
+		// The range covers the calendar days of the requested zone.
+		year, month, day := start.In(loc).Date()
+		start, end = time.Date(year, month, day, 0, 0, 0, 0, loc), end.In(loc)
+	}
+
+	opts, err := parseRangeOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return rangeRequest{}, false
+	}
+
+	u, err := parseUnits(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return rangeRequest{}, false
+	}
+
+	envelope, err := parseBool(r, "envelope")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return rangeRequest{}, false
+	}
+
+	f, err := negotiateFormat(r)
+	if err != nil {
+		writeError(w, http.StatusNotAcceptable, CodeNotAcceptable, err.Error())
+		return rangeRequest{}, false
+	}
+
+	// The units are echoed even if they are the default, so that clients
+	// need not assume which ones readings are in.
+	meta := map[string]interface{}{"units": u}
+
+	return rangeRequest{start: start, end: end, opts: opts, units: u, format: f, meta: meta, envelope: envelope}, true
+}
+
+// parseRangeOptions returns the range fetching options requested through
+// query parameters.
+func parseRangeOptions(r *http.Request) ([]rangefetcher.Option, error) {
+	opts := make([]rangefetcher.Option, 0)
+
+	if policyStr := r.FormValue("policy"); policyStr != "" {
+		policy, err := rangefetcher.ParsePolicy(policyStr)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, rangefetcher.WithPolicy(policy))
+	}
+
+	stepStr, err := samplingStep(r)
+	if err != nil {
+		return nil, err
+	}
+	if stepStr != "" {
+		step, err := rangefetcher.ParseInterval(stepStr)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, rangefetcher.WithInterval(step))
+	}
+
+	clamp, err := parseBool(r, "clamp")
+	if err != nil {
+		return nil, err
+	}
+	if clamp {
+		opts = append(opts, rangefetcher.Clamp())
+	}
+
+	return opts, nil
+}
+
+// parseRange parses the range requested either through the `start` and `end`
+// query parameters or through an ISO 8601 `interval`. Dates and times without
+// a UTC offset are in loc, or in UTC if it is nil.
+func parseRange(r *http.Request, loc *time.Location) (time.Time, time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	if interval := r.FormValue("interval"); isISOInterval(interval) {
+		if r.FormValue("start") != "" || r.FormValue("end") != "" {
+			return time.Time{}, time.Time{}, errors.New("either `start` and `end` or an ISO 8601 `interval` must be given, not both")
+		}
+		start, end, err := iso8601.ParseInterval(interval, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("`interval` must be an ISO 8601 interval, %v", err)
+		}
+		// Ranges include their end but ISO 8601 intervals do not.
+		return start, end.Add(-time.Nanosecond), nil
+	}
+
+	start, err := iso8601.ParseInLocation(r.FormValue("start"), loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("`start` must be an ISO 8601 date or datetime")
+	}
+	end, err := iso8601.ParseInLocation(r.FormValue("end"), loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("`end` must be an ISO 8601 date or datetime")
+	}
+	return start, end, nil
+}
+
+// samplingStep returns the step the range is requested to be sampled at,
+// through the `step` query parameter or its alias `interval` when that is not
+// an ISO 8601 interval, or empty if the range is sampled daily.
+func samplingStep(r *http.Request) (string, error) {
+	step, interval := r.FormValue("step"), r.FormValue("interval")
+	if interval == "" || isISOInterval(interval) {
+		return step, nil
+	}
+	if step != "" {
+		return "", errors.New("`step` and `interval` both give the sampling step, only one of them may be given")
+	}
+	return interval, nil
+}
+
+// isISOInterval reports whether the `interval` query parameter is an ISO 8601
+// interval giving the range rather than the step it is sampled at.
+func isISOInterval(interval string) bool {
+	return strings.Contains(interval, "/")
+}
+
+// parseZone returns the time zone requested through the `tz` query
+// parameter, or nil if none is.
+func parseZone(r *http.Request) (*time.Location, error) {
+	tz := r.FormValue("tz")
+	if tz == "" {
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("`tz` must be an IANA time zone name, %v", err)
+	}
+	return loc, nil
+}
+
+// parseBool parses the boolean query parameter name, which defaults to false.
+func parseBool(r *http.Request, name string) (bool, error) {
+	s := r.FormValue(name)
+	if s == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("`%s` must be a boolean", name)
+	}
+	return b, nil
+}
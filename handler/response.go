@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	"github.com/svranesevic/charlyedu/serviceerror"
+	log "go.uber.org/zap"
+)
+
+// rangeResponse is the body of a range response which, besides the readings
+// themselves, has to tell the client which days are missing from them,
+// describe how the readings were computed or point to the next page.
+type rangeResponse struct {
+	Data    interface{}            `json:"data"`
+	Missing []missingDay           `json:"missing"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+	Next    string                 `json:"next,omitempty"`
+}
+
+type missingDay struct {
+	Date        time.Time `json:"date"`
+	Code        string    `json:"code"`
+	Description string    `json:"message"`
+}
+
+// writeRange writes the readings of a range in the format negotiated for
+// params. The readings are written on their own, whichever options were
+// requested, with the days missing from them under the Partial policy in the
+// X-Missing-Dates and X-Missing-Count headers and the request's meta in the
+// X-Meta header. Only if the client asks for it with `envelope=true` are JSON
+// readings wrapped together with every day missing, the meta and the cursor
+// to the next page instead.
+// The next page is linked to in the Link header regardless.
+func writeRange(w http.ResponseWriter, params rangeRequest, data interface{}, report rangefetcher.Report) {
+	setLinkHeader(w, params, report)
+
+	if params.format.newWriter == nil {
+		var body interface{} = data
+		if params.envelope {
+			body = rangeResponse{Data: data, Missing: missingDays(report), Meta: params.meta, Next: nextCursor(params, report)}
+		} else {
+			if report.Policy == rangefetcher.Partial && len(report.Missing) > 0 {
+				setMissingHeaders(w.Header(), report)
+			}
+			setMetaHeader(w, params.meta)
+		}
+
+		w.Header().Set("Content-Type", params.format.contentType)
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			log.S().Errorf("Failed to marshal response: %+v", err)
+			writeError(w, http.StatusInternalServerError, CodeInternal, "Woops, something went wrong, try again")
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeRecords(&buf, params.format, data); err != nil {
+		log.S().Errorf("Failed to marshal response: %+v", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Woops, something went wrong, try again")
+		return
+	}
+
+	if report.Policy == rangefetcher.Partial && len(report.Missing) > 0 {
+		setMissingHeaders(w.Header(), report)
+	}
+	setMetaHeader(w, params.meta)
+
+	w.Header().Set("Content-Type", params.format.contentType)
+	buf.WriteTo(w)
+}
+
+// writeRecords writes the readings in data, a slice, in format f.
+func writeRecords(w io.Writer, f format, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var records []json.RawMessage
+	if err := json.Unmarshal(b, &records); err != nil {
+		return err
+	}
+
+	rw := f.newWriter(w)
+	for _, record := range records {
+		if err := rw.write(record); err != nil {
+			return err
+		}
+	}
+	return rw.flush()
+}
+
+func missingDays(report rangefetcher.Report) []missingDay {
+	missing := make([]missingDay, len(report.Missing))
+	for i, m := range report.Missing {
+		err := m.Err
+		if err == nil {
+			err = serviceerror.ErrNotFound
+		}
+		missing[i] = missingDay{Date: m.Date, Code: errorMappingFor(err).code, Description: err.Error()}
+	}
+	return missing
+}
+
+// maxMissingDates is how many of the days missing from a range the
+// X-Missing-Dates header lists at most, so that it stays within the header
+// size limits of proxies however long the range is. X-Missing-Count has the
+// number of all of them.
+const maxMissingDates = 100
+
+// setMissingHeaders sets the X-Missing-Dates and X-Missing-Count headers, or
+// trailers, of a range in h.
+func setMissingHeaders(h http.Header, report rangefetcher.Report) {
+	missing := report.Missing
+	if len(missing) > maxMissingDates {
+		missing = missing[:maxMissingDates]
+	}
+
+	dates := make([]string, len(missing))
+	for i, m := range missing {
+		dates[i] = m.Date.Format(time.RFC3339)
+	}
+	h.Set("X-Missing-Dates", strings.Join(dates, ","))
+	h.Set("X-Missing-Count", strconv.Itoa(len(report.Missing)))
+}
+
+func setMetaHeader(w http.ResponseWriter, meta map[string]interface{}) {
+	if len(meta) == 0 {
+		return
+	}
+	if b, err := json.Marshal(meta); err == nil {
+		w.Header().Set("X-Meta", string(b))
+	}
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/rangefetcher"
+)
+
+func TestSetMissingHeadersListsAtMostMaxMissingDates(t *testing.T) {
+	report := rangefetcher.Report{Policy: rangefetcher.Partial}
+	for i := 0; i < 3*maxMissingDates; i++ {
+		report.Missing = append(report.Missing, rangefetcher.Missing{Date: time.Date(1900, 1, 1+i, 0, 0, 0, 0, time.UTC)})
+	}
+
+	h := make(http.Header)
+	setMissingHeaders(h, report)
+
+	dates := strings.Split(h.Get("X-Missing-Dates"), ",")
+	assert.Len(t, dates, maxMissingDates)
+	assert.Equal(t, "1900-01-01T00:00:00Z", dates[0])
+	assert.Equal(t, "300", h.Get("X-Missing-Count"))
+}
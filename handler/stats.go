@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	"github.com/svranesevic/charlyedu/stats"
+	"github.com/svranesevic/charlyedu/temperatureservice"
+	"github.com/svranesevic/charlyedu/weatherservice"
+	"github.com/svranesevic/charlyedu/windspeedservice"
+)
+
+var defaultPercentiles = []float64{25, 75, 95}
+
+// statsRequest is how the readings of a range are requested to be summarized.
+type statsRequest struct {
+	bucket      stats.Bucket
+	percentiles []float64
+}
+
+func GetTemperatureStats(ts temperatureservice.Service, w http.ResponseWriter, r *http.Request) {
+	params, ok := parseRangeRequest(w, r)
+	if !ok {
+		return
+	}
+	sp, ok := parseStatsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var report rangefetcher.Report
+	temps, err := ts.GetForRange(r.Context(), params.start, params.end, append(params.opts, rangefetcher.ReportTo(&report))...)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	observations := make([]stats.Observation, len(temps))
+	for i, t := range temps {
+		t = convertTemperature(t, params.units)
+		observations[i] = stats.Observation{Date: t.Date, Values: map[string]float64{"temp": t.Temperature}}
+	}
+
+	writeRange(w, params, stats.Aggregate(observations, sp.bucket, sp.percentiles), report)
+}
+
+func GetWindSpeedStats(wss windspeedservice.Service, w http.ResponseWriter, r *http.Request) {
+	params, ok := parseRangeRequest(w, r)
+	if !ok {
+		return
+	}
+	sp, ok := parseStatsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var report rangefetcher.Report
+	windSpeeds, err := wss.GetForRange(r.Context(), params.start, params.end, append(params.opts, rangefetcher.ReportTo(&report))...)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	observations := make([]stats.Observation, len(windSpeeds))
+	for i, ws := range windSpeeds {
+		ws = convertWindSpeed(ws, params.units)
+		observations[i] = stats.Observation{Date: ws.Date, Values: map[string]float64{"north": ws.North, "west": ws.West}}
+	}
+
+	writeRange(w, params, stats.Aggregate(observations, sp.bucket, sp.percentiles), report)
+}
+
+func GetWeatherStats(ws weatherservice.Service, w http.ResponseWriter, r *http.Request) {
+	params, ok := parseRangeRequest(w, r)
+	if !ok {
+		return
+	}
+	sp, ok := parseStatsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var report rangefetcher.Report
+	weathers, err := ws.GetForRange(r.Context(), params.start, params.end, append(params.opts, rangefetcher.ReportTo(&report))...)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	observations := make([]stats.Observation, len(weathers))
+	for i, weather := range weathers {
+		weather = convertWeather(weather, params.units)
+		observations[i] = stats.Observation{Date: weather.Date, Values: map[string]float64{
+			"temp":  weather.Temperature,
+			"north": weather.North,
+			"west":  weather.West,
+		}}
+	}
+
+	writeRange(w, params, stats.Aggregate(observations, sp.bucket, sp.percentiles), report)
+}
+
+// unsupportedStatsParameters are the parameters of ranges which statistics
+// can not be computed with, as they summarize whole ranges at once.
+var unsupportedStatsParameters = []string{"limit", "cursor", "stream"}
+
+// parseStatsRequest parses the `bucket` and `percentiles` query parameters,
+// which default to day and 25,75,95. If they are invalid, or parameters
+// statistics do not support are given, it writes a Bad Request response and
+// returns false.
+func parseStatsRequest(w http.ResponseWriter, r *http.Request) (statsRequest, bool) {
+	sp := statsRequest{bucket: stats.Day, percentiles: defaultPercentiles}
+
+	for _, name := range unsupportedStatsParameters {
+		if r.FormValue(name) != "" {
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("`%s` is not supported for statistics", name))
+			return statsRequest{}, false
+		}
+	}
+
+	if bucketStr := r.FormValue("bucket"); bucketStr != "" {
+		bucket, err := stats.ParseBucket(bucketStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+			return statsRequest{}, false
+		}
+		sp.bucket = bucket
+	}
+
+	if percentilesStr := r.FormValue("percentiles"); percentilesStr != "" {
+		sp.percentiles = make([]float64, 0)
+		for _, s := range strings.Split(percentilesStr, ",") {
+			p, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			// NaN compares false to every bound, so it is ruled out first.
+			if err != nil || math.IsNaN(p) || math.IsInf(p, 0) || p < 0 || p > 100 {
+				writeError(w, http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("invalid percentile %q, must be a number between 0 and 100", s))
+				return statsRequest{}, false
+			}
+			sp.percentiles = append(sp.percentiles, p)
+		}
+	}
+
+	return sp, true
+}
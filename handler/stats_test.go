@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/stats"
+	"github.com/svranesevic/charlyedu/temperatureservice"
+)
+
+func TestGetTemperatureStatsSummarizesBuckets(t *testing.T) {
+	tempService := temperatureServiceStub{
+		Temperatures: []temperatureservice.Temperature{
+			{Date: time.Date(2019, 1, 30, 0, 0, 0, 0, time.UTC), Temperature: 1},
+			{Date: time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC), Temperature: 3},
+			{Date: time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC), Temperature: 10},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures/stats?start=2019-01-30T00:00:00Z&end=2019-02-01T00:00:00Z&bucket=month&percentiles=50", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperatureStats(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var summaries []stats.BucketSummary
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &summaries))
+
+	assert.Equal(t, []stats.BucketSummary{
+		{
+			Start: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			Stats: map[string]stats.Summary{
+				"temp": {Count: 2, Min: 1, Max: 3, Mean: 2, Median: 2, StdDev: 1, Percentiles: map[string]float64{"p50": 2}},
+			},
+		},
+		{
+			Start: time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC),
+			Stats: map[string]stats.Summary{
+				"temp": {Count: 1, Min: 10, Max: 10, Mean: 10, Median: 10, Percentiles: map[string]float64{"p50": 10}},
+			},
+		},
+	}, summaries)
+}
+
+func TestGetTemperatureStatsReturnsBadRequestErrorOnUnknownBucket(t *testing.T) {
+	tempService := temperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures/stats?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&bucket=decade", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperatureStats(tempService, rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetTemperatureStatsReturnsBadRequestErrorOnInvalidPercentile(t *testing.T) {
+	tempService := temperatureServiceStub{}
+
+	for _, percentiles := range []string{"50,101", "-1", "NaN", "nan", "Inf", "-Inf", "+Infinity"} {
+		req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures/stats?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&percentiles="+url.QueryEscape(percentiles), nil)
+		assert.Nil(t, err)
+
+		rec := httptest.NewRecorder()
+		GetTemperatureStats(tempService, rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, percentiles)
+	}
+}
+
+func TestGetTemperatureStatsReturnsBadRequestErrorOnPagingOrStreaming(t *testing.T) {
+	tempService := temperatureServiceStub{}
+
+	for _, query := range []string{"limit=2", "cursor=abc", "stream=true"} {
+		req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures/stats?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&"+query, nil)
+		assert.Nil(t, err)
+
+		rec := httptest.NewRecorder()
+		GetTemperatureStats(tempService, rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+
+		var res errorResponse
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		assert.Equal(t, CodeInvalidParameter, res.Code, query)
+	}
+}
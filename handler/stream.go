@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	log "go.uber.org/zap"
+)
+
+// rangeStream writes the readings of a range as they are fetched, in the
+// format negotiated for the request. JSON is written as the same array or
+// envelope it is written as otherwise, the days missing from the envelope
+// being written after the readings. Responses without an envelope carry the
+// days missing in the X-Missing-Dates and X-Missing-Count trailers.
+type rangeStream struct {
+	w       http.ResponseWriter
+	params  rangeRequest
+	report  *rangefetcher.Report
+	records recordWriter
+	started bool
+	written int
+}
+
+// streamRange streams the readings fetch obtains through the options it is
+// given, each passed through transform before being written. If fetch fails
+// before any reading was written the error is responded with as usual,
+// otherwise the response is cut short.
+func streamRange(w http.ResponseWriter, params rangeRequest, fetch func(opts ...rangefetcher.Option) error, transform func(interface{}) interface{}) {
+	var report rangefetcher.Report
+	s := &rangeStream{w: w, params: params, report: &report}
+
+	err := fetch(append(params.opts, rangefetcher.ReportTo(&report), rangefetcher.StreamTo(func(r interface{}) error {
+		return s.write(transform(r))
+	}))...)
+	if err != nil && !s.started {
+		writeServiceError(w, err)
+		return
+	}
+	if err != nil {
+		s.fail(err)
+		return
+	}
+
+	if err := s.finish(); err != nil {
+		log.S().Errorf("Failed to stream response: %+v", err)
+	}
+}
+
+func (s *rangeStream) start() error {
+	s.started = true
+
+	setLinkHeader(s.w, s.params, *s.report)
+	s.w.Header().Set("Content-Type", s.params.format.contentType)
+	if s.params.format.newWriter == nil && s.params.envelope {
+		s.w.WriteHeader(http.StatusOK)
+		_, err := s.w.Write([]byte(`{"data":[`))
+		return err
+	}
+
+	if s.report.Policy == rangefetcher.Partial {
+		s.w.Header().Set("Trailer", "X-Missing-Dates, X-Missing-Count")
+	}
+	setMetaHeader(s.w, s.params.meta)
+	s.w.WriteHeader(http.StatusOK)
+
+	if s.params.format.newWriter == nil {
+		_, err := s.w.Write([]byte("["))
+		return err
+	}
+	s.records = s.params.format.newWriter(s.w)
+	return nil
+}
+
+func (s *rangeStream) write(r interface{}) error {
+	record, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+
+	if s.records != nil {
+		if err := s.records.write(record); err != nil {
+			return err
+		}
+		if err := s.records.flush(); err != nil {
+			return err
+		}
+	} else {
+		if s.written > 0 {
+			if _, err := s.w.Write([]byte(",")); err != nil {
+				return err
+			}
+		}
+		if _, err := s.w.Write(record); err != nil {
+			return err
+		}
+	}
+	s.written++
+
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+func (s *rangeStream) finish() error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+
+	if !s.params.envelope || s.records != nil {
+		if s.report.Policy == rangefetcher.Partial {
+			setMissingHeaders(s.w.Header(), *s.report)
+		}
+	}
+	if s.records != nil {
+		return s.records.flush()
+	}
+
+	if !s.params.envelope {
+		_, err := s.w.Write([]byte("]\n"))
+		return err
+	}
+
+	tail, err := json.Marshal(struct {
+		Missing []missingDay           `json:"missing"`
+		Meta    map[string]interface{} `json:"meta,omitempty"`
+		Next    string                 `json:"next,omitempty"`
+	}{missingDays(*s.report), s.params.meta, nextCursor(s.params, *s.report)})
+	if err != nil {
+		return err
+	}
+	// Splice the tail's fields into the envelope after the data.
+	_, err = s.w.Write(append(append([]byte("],"), tail[1:]...), '\n'))
+	return err
+}
+
+// fail ends a stream which could not be completed. NDJSON ends with a line
+// holding the error response; other formats can not be told apart from a
+// complete response, so the connection is aborted instead.
+func (s *rangeStream) fail(err error) {
+	log.S().Errorf("Failed to stream response: %+v", err)
+
+	if s.params.format.name == ndjsonFormat.name {
+		mapping := errorMappingFor(err)
+		s.w.Write([]byte(NewErrorResponse(mapping.code, err.Error()) + "\n"))
+		return
+	}
+	panic(http.ErrAbortHandler)
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/temperatureservice"
+)
+
+func TestGetTemperatureStreamsSameArrayAsBufferedResponse(t *testing.T) {
+	tempService := gappyTemperatureServiceStub{}
+
+	buffered := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-15T00:00:00Z&interval=7d", nil)
+	assert.Nil(t, err)
+	GetTemperature(tempService, buffered, req)
+
+	streamed := httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-15T00:00:00Z&interval=7d&stream=true", nil)
+	assert.Nil(t, err)
+	GetTemperature(tempService, streamed, req)
+
+	assert.Equal(t, http.StatusOK, streamed.Code)
+	assert.Equal(t, "application/json", streamed.Header().Get("Content-Type"))
+	assert.JSONEq(t, buffered.Body.String(), streamed.Body.String())
+}
+
+func TestGetTemperatureStreamsEnvelopeUnderPartialPolicy(t *testing.T) {
+	tempService := gappyTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&policy=partial&stream=true&envelope=true", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res struct {
+		Data    []temperatureservice.Temperature `json:"data"`
+		Missing []missingDay                     `json:"missing"`
+	}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	assert.Len(t, res.Data, 2)
+	assert.Len(t, res.Missing, 1)
+	assert.Equal(t, time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), res.Missing[0].Date)
+}
+
+func TestGetTemperatureStreamsBareArrayWithoutEnvelope(t *testing.T) {
+	tempService := gappyTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&policy=partial&stream=true", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res []temperatureservice.Temperature
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	assert.Len(t, res, 2)
+	assert.Equal(t, "2019-01-02T00:00:00Z", rec.Result().Trailer.Get("X-Missing-Dates"))
+}
+
+func TestGetTemperatureStreamsCSVWithMissingDatesTrailer(t *testing.T) {
+	tempService := gappyTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&policy=partial&stream=true&format=csv", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	res := rec.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "temp,date\n1.1,2019-01-01T00:00:00Z\n1.1,2019-01-03T00:00:00Z\n", rec.Body.String())
+	assert.Equal(t, "2019-01-02T00:00:00Z", res.Trailer.Get("X-Missing-Dates"))
+	assert.Equal(t, "1", res.Trailer.Get("X-Missing-Count"))
+}
+
+func TestGetTemperatureStreamEndsNDJSONWithErrorWhenDayIsMissing(t *testing.T) {
+	tempService := gappyTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&stream=true&format=ndjson", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	dec := json.NewDecoder(rec.Body)
+	var temp temperatureservice.Temperature
+	assert.Nil(t, dec.Decode(&temp))
+	assert.Equal(t, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), temp.Date)
+
+	var res errorResponse
+	assert.Nil(t, dec.Decode(&res))
+	assert.Equal(t, CodeNotFound, res.Code)
+	assert.False(t, dec.More())
+}
+
+func TestGetTemperatureStreamRespondsWithErrorWhenNothingWasWritten(t *testing.T) {
+	tempService := unavailableTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&stream=true", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
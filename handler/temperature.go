@@ -1,38 +1,43 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
-	"time"
 
+	"github.com/svranesevic/charlyedu/rangefetcher"
 	"github.com/svranesevic/charlyedu/temperatureservice"
-	log "go.uber.org/zap"
 )
 
 func GetTemperature(ts temperatureservice.Service, w http.ResponseWriter, r *http.Request) {
-	startStr := r.FormValue("start")
-	endStr := r.FormValue("end")
-
-	start, err := time.Parse("2006-01-02T15:04:05Z0700", startStr)
+	params, ok := parseRangeRequest(w, r)
+	if !ok || !parsePage(w, r, &params) {
+		return
+	}
+	stream, err := parseBool(r, "stream")
 	if err != nil {
-		http.Error(w, NewErrorResponse("`start` must be an ISO8601 DateTime"), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
 		return
 	}
 
-	end, err := time.Parse("2006-01-02T15:04:05Z0700", endStr)
-	if err != nil {
-		http.Error(w, NewErrorResponse("`end` must be an ISO8601 DateTime"), http.StatusBadRequest)
+	if stream {
+		streamRange(w, params, func(opts ...rangefetcher.Option) error {
+			_, err := ts.GetForRange(r.Context(), params.start, params.end, opts...)
+			return err
+		}, func(t interface{}) interface{} {
+			return convertTemperature(t.(temperatureservice.Temperature), params.units)
+		})
 		return
 	}
 
-	temps, err := ts.GetForRange(r.Context(), start, end)
+	var report rangefetcher.Report
+	temps, err := ts.GetForRange(r.Context(), params.start, params.end, append(params.opts, rangefetcher.ReportTo(&report))...)
 	if err != nil {
-		http.Error(w, NewErrorResponse(err.Error()), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
-	if err = json.NewEncoder(w).Encode(temps); err != nil {
-		log.S().Errorf("Failed to marshal response: %+v", err)
-		http.Error(w, NewErrorResponse("Woops, something went wrong, try again"), http.StatusInternalServerError)
+	for i := range temps {
+		temps[i] = convertTemperature(temps[i], params.units)
 	}
+
+	writeRange(w, params, temps, report)
 }
@@ -11,14 +11,18 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/circuitbreaker"
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	"github.com/svranesevic/charlyedu/serviceerror"
 	"github.com/svranesevic/charlyedu/temperatureservice"
+	"github.com/svranesevic/charlyedu/units"
 )
 
 type temperatureServiceStub struct {
 	Temperatures []temperatureservice.Temperature
 }
 
-func (s temperatureServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time) ([]temperatureservice.Temperature, error) {
+func (s temperatureServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]temperatureservice.Temperature, error) {
 	temps := make([]temperatureservice.Temperature, 0)
 	for _, temp := range s.Temperatures {
 		if temp.Date.After(from.Add(-24*time.Hour)) && temp.Date.Before(to.Add(24*time.Hour)) {
@@ -42,7 +46,7 @@ func (s temperatureServiceStub) GetForDateTime(ctx context.Context, at time.Time
 type phallicTemperatureServiceStub struct {
 }
 
-func (s phallicTemperatureServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time) ([]temperatureservice.Temperature, error) {
+func (s phallicTemperatureServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]temperatureservice.Temperature, error) {
 	return []temperatureservice.Temperature{}, errors.New("GetForRange error")
 }
 
@@ -50,6 +54,41 @@ func (s phallicTemperatureServiceStub) GetForDateTime(ctx context.Context, at ti
 	return nil, errors.New("GetForRange error")
 }
 
+type unavailableTemperatureServiceStub struct {
+}
+
+func (s unavailableTemperatureServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]temperatureservice.Temperature, error) {
+	return []temperatureservice.Temperature{}, circuitbreaker.ErrOpen
+}
+
+func (s unavailableTemperatureServiceStub) GetForDateTime(ctx context.Context, at time.Time) (*temperatureservice.Temperature, error) {
+	return nil, circuitbreaker.ErrOpen
+}
+
+// gappyTemperatureServiceStub has readings for every day of a range but its
+// second one.
+type gappyTemperatureServiceStub struct {
+}
+
+func (s gappyTemperatureServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]temperatureservice.Temperature, error) {
+	results, err := rangefetcher.New("temperature", 1).Fetch(ctx, from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		if at.Equal(from.Add(24 * time.Hour)) {
+			return nil, nil
+		}
+		return temperatureservice.Temperature{Date: at, Temperature: 1.1}, nil
+	}, opts...)
+
+	temps := make([]temperatureservice.Temperature, len(results))
+	for i, r := range results {
+		temps[i] = r.(temperatureservice.Temperature)
+	}
+	return temps, err
+}
+
+func (s gappyTemperatureServiceStub) GetForDateTime(ctx context.Context, at time.Time) (*temperatureservice.Temperature, error) {
+	return nil, nil
+}
+
 func TestGetTemperatureReturnsTemperatures(t *testing.T) {
 	tempService := temperatureServiceStub{
 		Temperatures: []temperatureservice.Temperature{
@@ -129,3 +168,340 @@ func TestGetTemperatureReturnsInternalServerErrorOnServiceError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 }
+
+func TestGetTemperatureReturnsServiceUnavailableErrorOnOpenCircuitBreaker(t *testing.T) {
+	tempService := unavailableTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-02-02T00:00:00Z", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestGetTemperatureReturnsNotFoundErrorOnMissingDay(t *testing.T) {
+	tempService := gappyTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	var res errorResponse
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, CodeNotFound, res.Code)
+}
+
+func TestGetTemperatureReturnsPartialResultUnderPartialPolicy(t *testing.T) {
+	tempService := gappyTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&policy=partial&envelope=true", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res struct {
+		Data    []temperatureservice.Temperature `json:"data"`
+		Missing []missingDay                     `json:"missing"`
+	}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	assert.Equal(t, []temperatureservice.Temperature{
+		{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Temperature: 1.1},
+		{Date: time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC), Temperature: 1.1},
+	}, res.Data)
+	assert.Equal(t, []missingDay{
+		{Date: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), Code: CodeNotFound, Description: serviceerror.ErrNotFound.Error()},
+	}, res.Missing)
+}
+
+func TestGetTemperatureReturnsBareArrayWithoutEnvelope(t *testing.T) {
+	tempService := gappyTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&policy=partial&temp_unit=f", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res []temperatureservice.Temperature
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	assert.Len(t, res, 2)
+	assert.Equal(t, "2019-01-02T00:00:00Z", rec.Header().Get("X-Missing-Dates"))
+	assert.Equal(t, "1", rec.Header().Get("X-Missing-Count"))
+	assert.Contains(t, rec.Header().Get("X-Meta"), `"units"`)
+}
+
+func TestGetTemperatureReturnsBadRequestErrorOnUnknownPolicy(t *testing.T) {
+	tempService := temperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&policy=lenient", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetTemperatureSamplesRangeAtInterval(t *testing.T) {
+	tempService := gappyTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-15T00:00:00Z&interval=7d", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var temps []temperatureservice.Temperature
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &temps))
+
+	assert.Equal(t, []temperatureservice.Temperature{
+		{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Temperature: 1.1},
+		{Date: time.Date(2019, 1, 8, 0, 0, 0, 0, time.UTC), Temperature: 1.1},
+		{Date: time.Date(2019, 1, 15, 0, 0, 0, 0, time.UTC), Temperature: 1.1},
+	}, temps)
+}
+
+func TestGetTemperatureReturnsBadRequestErrorOnInvalidInterval(t *testing.T) {
+	tempService := temperatureServiceStub{}
+
+	for _, interval := range []string{"fortnightly", "9223372036854775807d", "768614336404564650y"} {
+		req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&interval="+interval, nil)
+		assert.Nil(t, err)
+
+		rec := httptest.NewRecorder()
+		GetTemperature(tempService, rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, interval)
+	}
+}
+
+func TestGetTemperatureCoversCalendarDaysOfRequestedZone(t *testing.T) {
+	tempService := gappyTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-03-30T12:00:00Z&end=2019-04-01T00:00:00Z&tz=Europe/Berlin&policy=partial&envelope=true", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res struct {
+		Data []struct {
+			Date string `json:"date"`
+		} `json:"data"`
+		Missing []struct {
+			Date string `json:"date"`
+		} `json:"missing"`
+	}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	assert.Len(t, res.Data, 2)
+	assert.Equal(t, "2019-03-30T00:00:00+01:00", res.Data[0].Date)
+	assert.Equal(t, "2019-04-01T00:00:00+02:00", res.Data[1].Date)
+	assert.Len(t, res.Missing, 1)
+	assert.Equal(t, "2019-03-31T00:00:00+01:00", res.Missing[0].Date)
+}
+
+func TestGetTemperatureReturnsBadRequestErrorOnUnknownTimeZone(t *testing.T) {
+	tempService := temperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&tz=Mars/Olympus_Mons", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetTemperatureConvertsToRequestedUnitAndEchoesIt(t *testing.T) {
+	tempService := temperatureServiceStub{
+		Temperatures: []temperatureservice.Temperature{
+			{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Temperature: 10},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-01T00:00:00Z&temp_unit=f&envelope=true", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res struct {
+		Data []temperatureservice.Temperature `json:"data"`
+		Meta struct {
+			Units units.Units `json:"units"`
+		} `json:"meta"`
+	}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	assert.Equal(t, []temperatureservice.Temperature{
+		{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Temperature: 50},
+	}, res.Data)
+	assert.Equal(t, units.Units{Temperature: units.Fahrenheit, Speed: units.MetersPerSecond}, res.Meta.Units)
+}
+
+func TestGetTemperatureEchoesDefaultUnits(t *testing.T) {
+	tempService := temperatureServiceStub{
+		Temperatures: []temperatureservice.Temperature{
+			{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Temperature: 10},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-01T00:00:00Z", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var meta struct {
+		Units units.Units `json:"units"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(rec.Header().Get("X-Meta")), &meta))
+	assert.Equal(t, units.Metric, meta.Units)
+}
+
+func TestGetTemperatureReturnsBadRequestErrorOnUnknownUnits(t *testing.T) {
+	tempService := temperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2019-01-01T00:00:00Z&end=2019-01-03T00:00:00Z&units=furlongs", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetTemperatureReturnsUnprocessableEntityErrorOnDateBeforeSupportedWindow(t *testing.T) {
+	tempService := completeTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=1899-12-31T00:00:00Z&end=1900-01-02T00:00:00Z", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	var res errorResponse
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, CodeOutOfBounds, res.Code)
+	assert.Contains(t, res.Description, "supported window of 1900-01-01 through")
+}
+
+func TestGetTemperatureClampsRangeToSupportedWindow(t *testing.T) {
+	tempService := completeTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=1899-12-31T00:00:00Z&end=1900-01-02T00:00:00Z&clamp=true", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var temps []temperatureservice.Temperature
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &temps))
+	assert.Len(t, temps, 2)
+	assert.Equal(t, time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), temps[0].Date)
+}
+
+func TestGetTemperatureAcceptsISO8601DateForms(t *testing.T) {
+	tempService := completeTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2018-W31-3&end=2018-214", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var temps []temperatureservice.Temperature
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &temps))
+	assert.Len(t, temps, 2)
+	assert.Equal(t, time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC), temps[0].Date)
+	assert.Equal(t, time.Date(2018, 8, 2, 0, 0, 0, 0, time.UTC), temps[1].Date)
+}
+
+func TestGetTemperatureAcceptsISO8601IntervalInsteadOfStartAndEnd(t *testing.T) {
+	tempService := completeTemperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?interval=2018-08-01/P7D", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var temps []temperatureservice.Temperature
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &temps))
+	assert.Len(t, temps, 7)
+	assert.Equal(t, time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC), temps[0].Date)
+	assert.Equal(t, time.Date(2018, 8, 7, 0, 0, 0, 0, time.UTC), temps[6].Date)
+}
+
+func TestGetTemperatureReturnsBadRequestErrorOnISO8601IntervalAlongsideStart(t *testing.T) {
+	tempService := temperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2018-08-01&interval=2018-08-01/P7D", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetTemperatureSamplesISO8601IntervalAtStep(t *testing.T) {
+	tempService := completeTemperatureServiceStub{}
+
+	for _, query := range []string{"interval=2018-08-01/P14D&step=1w", "start=2018-08-01&end=2018-08-14&interval=1w", "start=2018-08-01&end=2018-08-14&step=1w"} {
+		req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?"+query, nil)
+		assert.Nil(t, err)
+
+		rec := httptest.NewRecorder()
+		GetTemperature(tempService, rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code, query)
+
+		var temps []temperatureservice.Temperature
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &temps))
+		assert.Len(t, temps, 2, query)
+		assert.Equal(t, time.Date(2018, 8, 8, 0, 0, 0, 0, time.UTC), temps[1].Date, query)
+	}
+}
+
+func TestGetTemperatureReturnsBadRequestErrorOnSamplingStepGivenTwice(t *testing.T) {
+	tempService := temperatureServiceStub{}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/temperatures?start=2018-08-01&end=2018-08-14&interval=1w&step=2d", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetTemperature(tempService, rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/svranesevic/charlyedu/temperatureservice"
+	"github.com/svranesevic/charlyedu/units"
+	"github.com/svranesevic/charlyedu/weatherservice"
+	"github.com/svranesevic/charlyedu/windspeedservice"
+)
+
+// parseUnits parses the units requested through the `units` query parameter,
+// which `temp_unit` and `speed_unit` override. Readings are given in metric
+// units unless others are requested.
+func parseUnits(r *http.Request) (units.Units, error) {
+	u := units.Metric
+
+	if system := r.FormValue("units"); system != "" {
+		var err error
+		if u, err = units.ParseSystem(system); err != nil {
+			return units.Metric, err
+		}
+	}
+
+	if tempUnit := r.FormValue("temp_unit"); tempUnit != "" {
+		temp, err := units.ParseTemperatureUnit(tempUnit)
+		if err != nil {
+			return units.Metric, err
+		}
+		u.Temperature = temp
+	}
+
+	if speedUnit := r.FormValue("speed_unit"); speedUnit != "" {
+		speed, err := units.ParseSpeedUnit(speedUnit)
+		if err != nil {
+			return units.Metric, err
+		}
+		u.Speed = speed
+	}
+
+	return u, nil
+}
+
+func convertTemperature(t temperatureservice.Temperature, u units.Units) temperatureservice.Temperature {
+	t.Temperature = u.Temperature.FromCelsius(t.Temperature)
+	return t
+}
+
+func convertWindSpeed(ws windspeedservice.WindSpeed, u units.Units) windspeedservice.WindSpeed {
+	ws.North = u.Speed.FromMetersPerSecond(ws.North)
+	ws.West = u.Speed.FromMetersPerSecond(ws.West)
+	ws.Derived = convertDerived(ws.Derived, u)
+	return ws
+}
+
+func convertWeather(w weatherservice.Weather, u units.Units) weatherservice.Weather {
+	w.Temperature = u.Temperature.FromCelsius(w.Temperature)
+	w.North = u.Speed.FromMetersPerSecond(w.North)
+	w.West = u.Speed.FromMetersPerSecond(w.West)
+	w.Derived = convertDerived(w.Derived, u)
+
+	if w.Comfort != nil {
+		c := *w.Comfort
+		if c.WindChill != nil {
+			windChill := u.Temperature.FromCelsius(*c.WindChill)
+			c.WindChill = &windChill
+		}
+		c.ApparentTemperature = u.Temperature.FromCelsius(c.ApparentTemperature)
+		w.Comfort = &c
+	}
+
+	return w
+}
+
+func convertDerived(d *windspeedservice.Derived, u units.Units) *windspeedservice.Derived {
+	if d == nil {
+		return nil
+	}
+
+	converted := *d
+	converted.Speed = u.Speed.FromMetersPerSecond(d.Speed)
+	return &converted
+}
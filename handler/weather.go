@@ -1,38 +1,74 @@
 package handler
 
 import (
-	"encoding/json"
+	"math"
 	"net/http"
-	"time"
 
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	"github.com/svranesevic/charlyedu/units"
 	"github.com/svranesevic/charlyedu/weatherservice"
-	log "go.uber.org/zap"
+	"github.com/svranesevic/charlyedu/windspeedservice"
 )
 
 func GetWeather(ws weatherservice.Service, w http.ResponseWriter, r *http.Request) {
-	startStr := r.FormValue("start")
-	endStr := r.FormValue("end")
-
-	start, err := time.Parse("2006-01-02T15:04:05Z0700", startStr)
+	params, ok := parseRangeRequest(w, r)
+	if !ok || !parsePage(w, r, &params) {
+		return
+	}
+	derived, err := parseBool(r, "derived")
 	if err != nil {
-		http.Error(w, NewErrorResponse("`start` must be an ISO8601 DateTime"), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
 		return
 	}
-
-	end, err := time.Parse("2006-01-02T15:04:05Z0700", endStr)
+	comfort, err := parseBool(r, "comfort")
 	if err != nil {
-		http.Error(w, NewErrorResponse("`end` must be an ISO8601 DateTime"), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
 		return
 	}
+	stream, err := parseBool(r, "stream")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	if comfort {
+		params.meta["comfort"] = weatherservice.ComfortFormulae
+	}
 
-	temps, err := ws.GetForRange(r.Context(), start, end)
+	if stream {
+		streamRange(w, params, func(opts ...rangefetcher.Option) error {
+			_, err := ws.GetForRange(r.Context(), params.start, params.end, opts...)
+			return err
+		}, func(weather interface{}) interface{} {
+			return presentWeather(weather.(weatherservice.Weather), derived, comfort, params.units)
+		})
+		return
+	}
+
+	var report rangefetcher.Report
+	temps, err := ws.GetForRange(r.Context(), params.start, params.end, append(params.opts, rangefetcher.ReportTo(&report))...)
 	if err != nil {
-		http.Error(w, NewErrorResponse(err.Error()), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
-	if err = json.NewEncoder(w).Encode(temps); err != nil {
-		log.S().Errorf("Failed to marshal response: %+v", err)
-		http.Error(w, NewErrorResponse("Woops, something went wrong, try again"), http.StatusInternalServerError)
+	for i := range temps {
+		temps[i] = presentWeather(temps[i], derived, comfort, params.units)
+	}
+
+	writeRange(w, params, temps, report)
+}
+
+// presentWeather prepares a weather reading to be written in the requested
+// units, with its derived wind properties and comfort metrics if requested.
+func presentWeather(weather weatherservice.Weather, derived bool, comfort bool, u units.Units) weatherservice.Weather {
+	if derived {
+		d := windspeedservice.Derive(weather.North, weather.West)
+		weather.Derived = &d
+	}
+	if comfort {
+		c := weatherservice.ComputeComfort(weather.Temperature, math.Hypot(weather.North, weather.West))
+		weather.Comfort = &c
 	}
+	return convertWeather(weather, u)
 }
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/rangefetcher"
 	"github.com/svranesevic/charlyedu/weatherservice"
 )
 
@@ -18,7 +19,7 @@ type weatherServiceStub struct {
 	Weathers []weatherservice.Weather
 }
 
-func (s weatherServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time) ([]weatherservice.Weather, error) {
+func (s weatherServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]weatherservice.Weather, error) {
 	temps := make([]weatherservice.Weather, 0)
 	for _, temp := range s.Weathers {
 		if temp.Date.After(from.Add(-24*time.Hour)) && temp.Date.Before(to.Add(24*time.Hour)) {
@@ -42,7 +43,7 @@ func (s weatherServiceStub) GetForDateTime(ctx context.Context, at time.Time) (*
 type phallicWeatherServiceStub struct {
 }
 
-func (s phallicWeatherServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time) ([]weatherservice.Weather, error) {
+func (s phallicWeatherServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]weatherservice.Weather, error) {
 	return []weatherservice.Weather{}, errors.New("GetForRange error")
 }
 
@@ -139,3 +140,62 @@ func TestGetWeatherReturnsInternalServerErrorOnOnServiceError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 }
+
+func TestGetWeatherIncludesComfortMetricsAndFormulaeWhenRequested(t *testing.T) {
+	weatherService := weatherServiceStub{
+		Weathers: []weatherservice.Weather{
+			{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Temperature: -10, North: 20 / 3.6},
+			{Date: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), Temperature: 20, North: 5},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/weather?start=2019-01-01T00:00:00Z&end=2019-01-02T00:00:00Z&comfort=true&envelope=true", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetWeather(weatherService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res struct {
+		Data []weatherservice.Weather `json:"data"`
+		Meta struct {
+			Comfort map[string]weatherservice.Formula `json:"comfort"`
+		} `json:"meta"`
+	}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	assert.Len(t, res.Data, 2)
+	assert.InDelta(t, -17.9, *res.Data[0].WindChill, 0.05)
+	assert.Nil(t, res.Data[1].WindChill)
+	assert.Equal(t, 20.0, res.Data[1].ApparentTemperature)
+	assert.Equal(t, weatherservice.ComfortFormulae, res.Meta.Comfort)
+}
+
+func TestGetWeatherConvertsEveryFieldToRequestedUnits(t *testing.T) {
+	weatherService := weatherServiceStub{
+		Weathers: []weatherservice.Weather{
+			{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Temperature: -10, North: 20 / 3.6},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/weather?start=2019-01-01T00:00:00Z&end=2019-01-01T00:00:00Z&comfort=true&derived=true&units=si&speed_unit=kmh&envelope=true", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetWeather(weatherService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res struct {
+		Data []weatherservice.Weather `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	assert.Len(t, res.Data, 1)
+	assert.InDelta(t, 263.15, res.Data[0].Temperature, 0.0001)
+	assert.InDelta(t, 20, res.Data[0].North, 0.0001)
+	assert.InDelta(t, 20, res.Data[0].Speed, 0.0001)
+	assert.InDelta(t, 255.25, *res.Data[0].WindChill, 0.05)
+	assert.InDelta(t, 255.25, res.Data[0].ApparentTemperature, 0.05)
+}
@@ -1,38 +1,59 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
-	"time"
 
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	"github.com/svranesevic/charlyedu/units"
 	"github.com/svranesevic/charlyedu/windspeedservice"
-	log "go.uber.org/zap"
 )
 
 func GetWindSpeed(wss windspeedservice.Service, w http.ResponseWriter, r *http.Request) {
-	startStr := r.FormValue("start")
-	endStr := r.FormValue("end")
-
-	start, err := time.Parse("2006-01-02T15:04:05Z0700", startStr)
+	params, ok := parseRangeRequest(w, r)
+	if !ok || !parsePage(w, r, &params) {
+		return
+	}
+	derived, err := parseBool(r, "derived")
 	if err != nil {
-		http.Error(w, NewErrorResponse("`start` must be an ISO8601 DateTime"), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
 		return
 	}
-
-	end, err := time.Parse("2006-01-02T15:04:05Z0700", endStr)
+	stream, err := parseBool(r, "stream")
 	if err != nil {
-		http.Error(w, NewErrorResponse("`end` must be an ISO8601 DateTime"), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
 		return
 	}
 
-	windSpeeds, err := wss.GetForRange(r.Context(), start, end)
+	if stream {
+		streamRange(w, params, func(opts ...rangefetcher.Option) error {
+			_, err := wss.GetForRange(r.Context(), params.start, params.end, opts...)
+			return err
+		}, func(ws interface{}) interface{} {
+			return presentWindSpeed(ws.(windspeedservice.WindSpeed), derived, params.units)
+		})
+		return
+	}
+
+	var report rangefetcher.Report
+	windSpeeds, err := wss.GetForRange(r.Context(), params.start, params.end, append(params.opts, rangefetcher.ReportTo(&report))...)
 	if err != nil {
-		http.Error(w, NewErrorResponse(err.Error()), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
-	if err = json.NewEncoder(w).Encode(windSpeeds); err != nil {
-		log.S().Errorf("Failed to marshal response: %+v", err)
-		http.Error(w, NewErrorResponse("Woops, something went wrong, try again"), http.StatusInternalServerError)
+	for i := range windSpeeds {
+		windSpeeds[i] = presentWindSpeed(windSpeeds[i], derived, params.units)
+	}
+
+	writeRange(w, params, windSpeeds, report)
+}
+
+// presentWindSpeed prepares a wind speed to be written in the requested
+// units, with its derived properties if requested.
+func presentWindSpeed(ws windspeedservice.WindSpeed, derived bool, u units.Units) windspeedservice.WindSpeed {
+	if derived {
+		d := windspeedservice.Derive(ws.North, ws.West)
+		ws.Derived = &d
 	}
+	return convertWindSpeed(ws, u)
 }
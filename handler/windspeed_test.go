@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/rangefetcher"
 	"github.com/svranesevic/charlyedu/windspeedservice"
 )
 
@@ -18,7 +19,7 @@ type windSpeedServiceStub struct {
 	WindSpeeds []windspeedservice.WindSpeed
 }
 
-func (s windSpeedServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time) ([]windspeedservice.WindSpeed, error) {
+func (s windSpeedServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]windspeedservice.WindSpeed, error) {
 	speeds := make([]windspeedservice.WindSpeed, 0)
 	for _, speed := range s.WindSpeeds {
 		if speed.Date.After(from.Add(-24*time.Hour)) && speed.Date.Before(to.Add(24*time.Hour)) {
@@ -42,7 +43,7 @@ func (s windSpeedServiceStub) GetForDateTime(ctx context.Context, at time.Time)
 type phallicWindSpeedServiceStub struct {
 }
 
-func (s phallicWindSpeedServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time) ([]windspeedservice.WindSpeed, error) {
+func (s phallicWindSpeedServiceStub) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]windspeedservice.WindSpeed, error) {
 	return []windspeedservice.WindSpeed{}, errors.New("GetForRange error")
 }
 
@@ -134,3 +135,48 @@ func TestGetWindSpeedReturnsInternalServerErrorOnOnServiceError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 }
+
+func TestGetWindSpeedIncludesDerivedFieldsWhenRequested(t *testing.T) {
+	windSpeedService := windSpeedServiceStub{
+		WindSpeeds: []windspeedservice.WindSpeed{
+			{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), North: -3, West: 4},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/speeds?start=2019-01-01T00:00:00Z&end=2019-01-01T00:00:00Z&derived=true", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetWindSpeed(windSpeedService, rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var speeds []map[string]interface{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &speeds))
+
+	assert.Len(t, speeds, 1)
+	assert.Equal(t, 5.0, speeds[0]["speed"])
+	assert.Equal(t, "NE", speeds[0]["compass"])
+	assert.Equal(t, 3.0, speeds[0]["beaufort"])
+	assert.Equal(t, "Gentle breeze", speeds[0]["beaufort_description"])
+}
+
+func TestGetWindSpeedOmitsDerivedFieldsByDefault(t *testing.T) {
+	windSpeedService := windSpeedServiceStub{
+		WindSpeeds: []windspeedservice.WindSpeed{
+			{Date: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), North: -3, West: 4},
+		},
+	}
+
+	req, err := http.NewRequest("GET", "http://url.handled.by.router/speeds?start=2019-01-01T00:00:00Z&end=2019-01-01T00:00:00Z", nil)
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	GetWindSpeed(windSpeedService, rec, req)
+
+	var speeds []map[string]interface{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &speeds))
+
+	assert.Len(t, speeds, 1)
+	assert.NotContains(t, speeds[0], "speed")
+}
@@ -0,0 +1,94 @@
+package iso8601
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var duration = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:[.,]\d+)?)S)?)?$`)
+
+// Duration is an ISO 8601 duration. Years, months and days are calendar
+// distances, so a day across a DST transition is not always 24 hours.
+type Duration struct {
+	Years  int
+	Months int
+	Days   int
+	Clock  time.Duration
+}
+
+// ParseDuration parses an ISO 8601 duration such as P7D, P1M, P2W or PT12H.
+func ParseDuration(s string) (Duration, error) {
+	m := duration.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return Duration{}, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+
+	seconds, _ := strconv.ParseFloat(strings.Replace(m[7], ",", ".", 1), 64)
+	return Duration{
+		Years:  atoi(m[1]),
+		Months: atoi(m[2]),
+		Days:   atoi(m[3])*7 + atoi(m[4]),
+		Clock:  time.Duration(atoi(m[5]))*time.Hour + time.Duration(atoi(m[6]))*time.Minute + time.Duration(seconds*float64(time.Second)),
+	}, nil
+}
+
+// AddTo returns t moved forward by d.
+func (d Duration) AddTo(t time.Time) time.Time {
+	return t.AddDate(d.Years, d.Months, d.Days).Add(d.Clock)
+}
+
+// SubtractFrom returns t moved back by d.
+func (d Duration) SubtractFrom(t time.Time) time.Time {
+	return t.Add(-d.Clock).AddDate(-d.Years, -d.Months, -d.Days)
+}
+
+// ParseInterval parses an ISO 8601 interval given by its start and end
+// (2018-08-01/2018-08-08), its start and duration (2018-08-01/P7D) or its
+// duration and end (P7D/2018-08-08). Dates and times without an offset are in
+// loc. As in ISO 8601, the interval does not include its end.
+func ParseInterval(s string, loc *time.Location) (time.Time, time.Time, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid ISO 8601 interval %q, must be start/end, start/duration or duration/end", s)
+	}
+
+	var start, end time.Time
+	var err error
+	switch {
+	case strings.HasPrefix(parts[0], "P") && strings.HasPrefix(parts[1], "P"):
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid ISO 8601 interval %q, must be start/end, start/duration or duration/end", s)
+	case strings.HasPrefix(parts[1], "P"):
+		if start, err = ParseInLocation(parts[0], loc); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		d, err := ParseDuration(parts[1])
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		end = d.AddTo(start)
+	case strings.HasPrefix(parts[0], "P"):
+		if end, err = ParseInLocation(parts[1], loc); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		d, err := ParseDuration(parts[0])
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		start = d.SubtractFrom(end)
+	default:
+		if start, err = ParseInLocation(parts[0], loc); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if end, err = ParseInLocation(parts[1], loc); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid ISO 8601 interval %q, its start must be before its end", s)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,50 @@
+package iso8601
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	d, err := ParseDuration("P1Y2M3DT4H5M6.5S")
+	assert.Nil(t, err)
+	assert.Equal(t, Duration{Years: 1, Months: 2, Days: 3, Clock: 4*time.Hour + 5*time.Minute + 6500*time.Millisecond}, d)
+
+	d, err = ParseDuration("P2W")
+	assert.Nil(t, err)
+	assert.Equal(t, Duration{Days: 14}, d)
+
+	for _, s := range []string{"", "P", "PT", "P1DT", "7D", "P1.5D"} {
+		_, err := ParseDuration(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		s     string
+		start time.Time
+		end   time.Time
+	}{
+		{"2018-08-01/P7D", time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 8, 8, 0, 0, 0, 0, time.UTC)},
+		{"2018-08-01/2018-08-08", time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 8, 8, 0, 0, 0, 0, time.UTC)},
+		{"P1M/2018-08-01", time.Date(2018, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"2018-W31/P1W", time.Date(2018, 7, 30, 0, 0, 0, 0, time.UTC), time.Date(2018, 8, 6, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		start, end, err := ParseInterval(test.s, time.UTC)
+		assert.Nil(t, err, test.s)
+		assert.Equal(t, test.start, start, test.s)
+		assert.Equal(t, test.end, end, test.s)
+	}
+}
+
+func TestParseIntervalReturnsErrorOnInvalidInterval(t *testing.T) {
+	for _, s := range []string{"2018-08-01", "P7D/P7D", "2018-08-08/2018-08-01", "2018-08-01/P0D", "2018-08-01/P7D/P7D"} {
+		_, _, err := ParseInterval(s, time.UTC)
+		assert.NotNil(t, err, s)
+	}
+}
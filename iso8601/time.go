@@ -0,0 +1,137 @@
+package iso8601
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	calendarDate = regexp.MustCompile(`^(\d{4})-?(\d{2})-?(\d{2})$`)
+	weekDate     = regexp.MustCompile(`^(\d{4})-?W(\d{2})(?:-?([1-7]))?$`)
+	ordinalDate  = regexp.MustCompile(`^(\d{4})-?(\d{3})$`)
+	timeOfDay    = regexp.MustCompile(`^(\d{2})(?::?(\d{2})(?::?(\d{2})(?:[.,](\d{1,9}))?)?)?(Z|[+-]\d{2}(?::?\d{2})?)?$`)
+)
+
+// Parse parses an ISO 8601 date or datetime. Dates may be calendar dates
+// (2018-08-01), week dates (2018-W31-3) or ordinal dates (2018-213), in their
+// extended or basic form, and may be followed by a time of day with optional
+// fractional seconds and UTC offset. Dates and times without an offset are in
+// UTC.
+func Parse(s string) (time.Time, error) {
+	return ParseInLocation(s, time.UTC)
+}
+
+// ParseInLocation is like Parse but takes dates and times without an offset
+// to be in loc.
+func ParseInLocation(s string, loc *time.Location) (time.Time, error) {
+	datePart, timePart := s, ""
+	if i := strings.IndexAny(s, "Tt"); i >= 0 {
+		datePart, timePart = s[:i], s[i+1:]
+	}
+
+	year, month, day, ok := parseDate(datePart)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid ISO 8601 date %q", s)
+	}
+	if timePart == "" && datePart != s {
+		return time.Time{}, fmt.Errorf("invalid ISO 8601 datetime %q", s)
+	}
+	if timePart == "" {
+		return time.Date(year, month, day, 0, 0, 0, 0, loc), nil
+	}
+
+	m := timeOfDay.FindStringSubmatch(timePart)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("invalid ISO 8601 datetime %q", s)
+	}
+	hour, min, sec := atoi(m[1]), atoi(m[2]), atoi(m[3])
+	nsec := 0
+	if m[4] != "" {
+		nsec = atoi((m[4] + "00000000")[:9])
+	}
+	// 24:00 is the end of a day, which is the beginning of the next one.
+	if hour > 24 || min > 59 || sec > 59 || hour == 24 && (min != 0 || sec != 0 || nsec != 0) {
+		return time.Time{}, fmt.Errorf("invalid ISO 8601 time of day in %q", s)
+	}
+
+	if m[5] != "" {
+		if loc = parseOffset(m[5]); loc == nil {
+			return time.Time{}, fmt.Errorf("invalid ISO 8601 UTC offset in %q", s)
+		}
+	}
+	return time.Date(year, month, day, hour, min, sec, nsec, loc), nil
+}
+
+// parseDate parses a calendar, week or ordinal date.
+func parseDate(s string) (int, time.Month, int, bool) {
+	// Either both separators of a calendar date are given or neither is.
+	if m := calendarDate.FindStringSubmatch(s); m != nil && strings.Count(s, "-") != 1 {
+		year, month, day := atoi(m[1]), time.Month(atoi(m[2])), atoi(m[3])
+		if month < time.January || month > time.December || day < 1 || day > daysIn(year, month) {
+			return 0, 0, 0, false
+		}
+		return year, month, day, true
+	}
+
+	if m := weekDate.FindStringSubmatch(s); m != nil {
+		year, week, weekday := atoi(m[1]), atoi(m[2]), 1
+		if m[3] != "" {
+			weekday = atoi(m[3])
+		}
+		if _, weeks := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek(); week < 1 || week > weeks {
+			return 0, 0, 0, false
+		}
+		// Week 1 is the one with the year's first Thursday, so it always
+		// contains the 4th of January.
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+		monday := jan4.AddDate(0, 0, -(int(jan4.Weekday())+6)%7)
+		y, mo, d := monday.AddDate(0, 0, (week-1)*7+weekday-1).Date()
+		return y, mo, d, true
+	}
+
+	if m := ordinalDate.FindStringSubmatch(s); m != nil {
+		year, day := atoi(m[1]), atoi(m[2])
+		if day < 1 || day > time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay() {
+			return 0, 0, 0, false
+		}
+		y, mo, d := time.Date(year, time.January, day, 0, 0, 0, 0, time.UTC).Date()
+		return y, mo, d, true
+	}
+
+	return 0, 0, 0, false
+}
+
+// parseOffset parses a UTC offset such as Z, +02, +0200 or -05:30.
+func parseOffset(s string) *time.Location {
+	if s == "Z" {
+		return time.UTC
+	}
+
+	digits := strings.Replace(s[1:], ":", "", 1)
+	hours, minutes := atoi(digits[:2]), 0
+	if len(digits) == 4 {
+		minutes = atoi(digits[2:])
+	}
+	if hours > 23 || minutes > 59 {
+		return nil
+	}
+
+	offset := hours*60*60 + minutes*60
+	if s[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone("", offset)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// atoi converts digits the patterns above have already matched.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
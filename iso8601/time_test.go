@@ -0,0 +1,58 @@
+package iso8601
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{"2018-08-01", time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"20180801", time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"2018-08-01T13:45:30Z", time.Date(2018, 8, 1, 13, 45, 30, 0, time.UTC)},
+		{"2018-08-01T13:45:30.250Z", time.Date(2018, 8, 1, 13, 45, 30, 250000000, time.UTC)},
+		{"2018-08-01T13:45", time.Date(2018, 8, 1, 13, 45, 0, 0, time.UTC)},
+		{"20180801T134530+0200", time.Date(2018, 8, 1, 11, 45, 30, 0, time.UTC)},
+		{"2018-08-01T13:45:30-05:30", time.Date(2018, 8, 1, 19, 15, 30, 0, time.UTC)},
+		{"2018-08-01T24:00:00Z", time.Date(2018, 8, 2, 0, 0, 0, 0, time.UTC)},
+		{"2018-W31-3", time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"2018W313", time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"2018-W31", time.Date(2018, 7, 30, 0, 0, 0, 0, time.UTC)},
+		{"2020-W53-7", time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"2019-W01-1", time.Date(2018, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"2018-213", time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"2018213T12:00Z", time.Date(2018, 8, 1, 12, 0, 0, 0, time.UTC)},
+		{"2016-366", time.Date(2016, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.s)
+		assert.Nil(t, err, test.s)
+		assert.True(t, test.want.Equal(got), "%s parsed as %s", test.s, got)
+	}
+}
+
+func TestParseReturnsErrorOnInvalidDates(t *testing.T) {
+	for _, s := range []string{"", "2018", "2018-13-01", "2018-02-29", "2018-0801", "2018-W53-1", "2018-W31-8", "2018-366", "2018-08-01T", "2018-08-01T25:00Z", "2018-08-01T13:45+2", "yesterday"} {
+		_, err := Parse(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestParseInLocationKeepsOffsetsGiven(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+
+	local, err := ParseInLocation("2018-08-01", tokyo)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2018, 8, 1, 0, 0, 0, 0, tokyo), local)
+
+	utc, err := ParseInLocation("2018-08-01T00:00:00Z", tokyo)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC), utc)
+}
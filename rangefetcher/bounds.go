@@ -0,0 +1,59 @@
+package rangefetcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/svranesevic/charlyedu/serviceerror"
+)
+
+// FirstDay is the first day the backing services have readings for. The
+// last one is today, UTC.
+var FirstDay = time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// bound checks that the days of a range are ones the backing services have
+// readings for, or if clamp trims the range to those days instead. Days are
+// compared by their calendar date in the range's own location, as that is the
+// UTC day they are fetched for.
+func bound(from time.Time, to time.Time, clamp bool, now time.Time) (time.Time, time.Time, error) {
+	today := date(now.UTC())
+
+	if clamp {
+		if date(from).Before(FirstDay) {
+			from = onDate(FirstDay, from)
+		}
+		if date(to).After(today) {
+			to = onDate(today, to)
+		}
+		if from.After(to) {
+			return from, to, outOfBounds("the range", today)
+		}
+		return from, to, nil
+	}
+
+	if date(from).Before(FirstDay) || date(from).After(today) {
+		return from, to, outOfBounds(fmt.Sprintf("start %s", from.Format("2006-01-02")), today)
+	}
+	if date(to).After(today) {
+		return from, to, outOfBounds(fmt.Sprintf("end %s", to.Format("2006-01-02")), today)
+	}
+	return from, to, nil
+}
+
+func outOfBounds(what string, today time.Time) error {
+	return serviceerror.New(serviceerror.OutOfBounds, fmt.Sprintf("%s is outside the supported window of %s through %s",
+		what, FirstDay.Format("2006-01-02"), today.Format("2006-01-02")))
+}
+
+// date returns the calendar date of t, as midnight UTC.
+func date(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// onDate returns the time on day d with the clock reading and location of t.
+func onDate(d time.Time, t time.Time) time.Time {
+	year, month, day := d.Date()
+	hour, min, sec := t.Clock()
+	return time.Date(year, month, day, hour, min, sec, t.Nanosecond(), t.Location())
+}
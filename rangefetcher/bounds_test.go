@@ -0,0 +1,78 @@
+package rangefetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/serviceerror"
+)
+
+func fetchDay(ctx context.Context, at time.Time) (interface{}, error) {
+	return at, nil
+}
+
+func TestFetchReturnsOutOfBoundsErrorBeforeFirstDay(t *testing.T) {
+	from := time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)
+	to := time.Date(1900, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	_, err := New("test", 1).Fetch(context.Background(), from, to, fetchDay)
+	assert.Equal(t, serviceerror.OutOfBounds, serviceerror.KindOf(err))
+	assert.Contains(t, err.Error(), "start 1899-12-31 is outside the supported window of 1900-01-01 through")
+}
+
+func TestFetchReturnsOutOfBoundsErrorAfterToday(t *testing.T) {
+	from := time.Now().UTC().AddDate(0, 0, -1)
+	to := time.Now().UTC().AddDate(0, 0, 1)
+
+	_, err := New("test", 1).Fetch(context.Background(), from, to, fetchDay)
+	assert.Equal(t, serviceerror.OutOfBounds, serviceerror.KindOf(err))
+}
+
+func TestFetchWithClampTrimsRangeToSupportedDays(t *testing.T) {
+	from := time.Date(1899, 12, 30, 12, 0, 0, 0, time.UTC)
+	to := time.Date(1900, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	readings, err := New("test", 1).Fetch(context.Background(), from, to, fetchDay, Clamp())
+	assert.Nil(t, err)
+
+	assert.Equal(t, []interface{}{
+		time.Date(1900, 1, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(1900, 1, 2, 12, 0, 0, 0, time.UTC),
+	}, readings)
+}
+
+func TestFetchWithClampReturnsOutOfBoundsErrorOnRangeEntirelyAfterToday(t *testing.T) {
+	from := time.Now().UTC().AddDate(0, 0, 1)
+	to := time.Now().UTC().AddDate(0, 0, 2)
+
+	_, err := New("test", 1).Fetch(context.Background(), from, to, fetchDay, Clamp())
+	assert.Equal(t, serviceerror.OutOfBounds, serviceerror.KindOf(err))
+}
+
+func TestFetchReturnsOutOfBoundsErrorOnMorePointsThanMax(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	_, err := New("test", 1, WithMaxPoints(30)).Fetch(context.Background(), from, to, fetchDay)
+	assert.Equal(t, serviceerror.OutOfBounds, serviceerror.KindOf(err))
+	assert.Contains(t, err.Error(), "response of 31 points exceeds the maximum of 30 points per response")
+
+	readings, err := New("test", 1, WithMaxPoints(30)).Fetch(context.Background(), from, to, fetchDay, WithInterval(Interval{Days: 7}))
+	assert.Nil(t, err)
+	assert.Len(t, readings, 5)
+}
+
+func TestFetchDoesNotLimitPointsOfStreamedRange(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	streamed := 0
+	_, err := New("test", 1, WithMaxPoints(30)).Fetch(context.Background(), from, to, fetchDay, StreamTo(func(r interface{}) error {
+		streamed++
+		return nil
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, 31, streamed)
+}
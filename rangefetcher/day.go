@@ -0,0 +1,31 @@
+package rangefetcher
+
+import (
+	"context"
+	"time"
+)
+
+// Dated is a reading of a single day.
+type Dated interface {
+	// InLocation returns a copy of the reading dated at the same wall clock
+	// in loc.
+	InLocation(loc *time.Location) Dated
+}
+
+// DayFunc obtains the reading of the UTC day of at. A nil reading with a nil
+// error means the day is not available.
+type DayFunc func(ctx context.Context, at time.Time) (Dated, error)
+
+// FetchDays is Fetch for the backing services' daily readings, which are
+// keyed by UTC date. Every point of the range is obtained through get as the
+// UTC day with the same date, and its reading is dated back in the location
+// of the range.
+func (f Fetcher) FetchDays(ctx context.Context, from time.Time, to time.Time, get DayFunc, opts ...Option) ([]interface{}, error) {
+	return f.Fetch(ctx, from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		r, err := get(ctx, SameWallClock(at, time.UTC))
+		if r == nil {
+			return nil, err
+		}
+		return r.InLocation(at.Location()), err
+	}, opts...)
+}
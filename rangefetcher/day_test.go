@@ -0,0 +1,38 @@
+package rangefetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type datedReading struct {
+	Date time.Time
+}
+
+func (r datedReading) InLocation(loc *time.Location) Dated {
+	r.Date = SameWallClock(r.Date, loc)
+	return r
+}
+
+func TestFetchDaysFetchesUTCDaysAndDatesReadingsInRangeLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, tokyo)
+	to := time.Date(2019, 1, 2, 0, 0, 0, 0, tokyo)
+
+	var fetched []time.Time
+	readings, err := New("test", 1).FetchDays(context.Background(), from, to, func(ctx context.Context, at time.Time) (Dated, error) {
+		fetched = append(fetched, at)
+		if at.Day() == 2 {
+			return nil, nil
+		}
+		return datedReading{Date: at}, nil
+	}, WithPolicy(Partial))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []time.Time{time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)}, fetched)
+	assert.Equal(t, []interface{}{datedReading{Date: from}}, readings)
+}
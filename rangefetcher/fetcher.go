@@ -0,0 +1,215 @@
+package rangefetcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/svranesevic/charlyedu/circuitbreaker"
+	"github.com/svranesevic/charlyedu/serviceerror"
+	log "go.uber.org/zap"
+)
+
+// FetchFunc obtains the reading for a single day. A nil reading with a nil
+// error means the day is not available.
+type FetchFunc func(ctx context.Context, at time.Time) (interface{}, error)
+
+// Fetcher fans a date range out into per-day fetches, running at most
+// concurrency of them at once, and collects the readings in date order.
+type Fetcher struct {
+	name        string
+	concurrency int
+	defaults    []Option
+}
+
+func New(name string, concurrency int, defaults ...Option) Fetcher {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return Fetcher{name: name, concurrency: concurrency, defaults: defaults}
+}
+
+// Days returns every day from `from` up to and including `to`.
+func Days(from time.Time, to time.Time) ([]time.Time, error) {
+	return Points(from, to, Daily)
+}
+
+// Points returns the points from `from` up to and including the day of `to`,
+// sampled every interval. Days are calendar days in the location of `from`,
+// so stepping across a DST transition neither skips nor repeats a day.
+func Points(from time.Time, to time.Time, interval Interval) ([]time.Time, error) {
+	if from.After(to) {
+		return []time.Time{}, serviceerror.ErrInvalidRange
+	}
+	year, month, day := to.In(from.Location()).Date()
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, from.Location())
+
+	points := make([]time.Time, 0)
+	for n, at := 0, from; at.Before(end); n, at = n+1, interval.after(from, n+1) {
+		// An interval overflowing the dates it steps through wraps around
+		// rather than passing the end.
+		if n > 0 && !at.After(points[n-1]) {
+			break
+		}
+		points = append(points, at)
+	}
+
+	return points, nil
+}
+
+// Fetch calls fetch for every point of the range, every day unless an
+// Interval is given, or only for those of a page of it, and returns the
+// readings in date order, or streams them if StreamTo is given. Ranges
+// reaching past the days the backing services have readings for are rejected
+// unless Clamp is given. Days which fail or are not available are handled
+// according to the Policy in effect, except that a day failing with
+// circuitbreaker.ErrOpen fails the whole range.
+func (f Fetcher) Fetch(ctx context.Context, from time.Time, to time.Time, fetch FetchFunc, opts ...Option) ([]interface{}, error) {
+	o := options{interval: Daily}
+	for _, opt := range f.defaults {
+		opt(&o)
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if from.After(to) {
+		return []interface{}{}, serviceerror.ErrInvalidRange
+	}
+	from, to, err := bound(from, to, o.clamp, time.Now())
+	if err != nil {
+		return []interface{}{}, err
+	}
+
+	days, err := Points(from, to, o.interval)
+	if err != nil {
+		return []interface{}{}, err
+	}
+	next := 0
+	if o.page != nil {
+		days, next = o.page.of(days)
+	}
+	// Streamed ranges are not held in memory, so they are not limited.
+	if o.maxPoints > 0 && o.stream == nil && len(days) > o.maxPoints {
+		return []interface{}{}, serviceerror.New(serviceerror.OutOfBounds, fmt.Sprintf(
+			"response of %d points exceeds the maximum of %d points per response, page through the range with limit, sample it with interval or stream it", len(days), o.maxPoints))
+	}
+	if o.report != nil {
+		*o.report = Report{Policy: o.policy, Missing: []Missing{}, Next: next}
+	}
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stream *streamer
+	if o.stream != nil {
+		stream = newStreamer(o.stream, len(days), f.concurrency*streamWindowPerWorker, o.policy, cancel)
+	}
+
+	// A day failing because the circuit breaker is not closed fails the whole
+	// range, as the days after it would fail alike. Days already in flight
+	// are left to finish rather than cancelled, so that the breaker's probe
+	// is still recorded.
+	aborted := make(chan struct{})
+	var abortOnce sync.Once
+	abort := func() {
+		abortOnce.Do(func() { close(aborted) })
+	}
+
+	results := make([]interface{}, len(days))
+	available := make([]bool, len(days))
+	errs := make([]error, len(days))
+	fetched := make([]bool, len(days))
+	dayChan := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < f.concurrency && w < len(days); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range dayChan {
+				r, err := fetch(fetchCtx, days[i])
+				if err != nil && fetchCtx.Err() != nil && ctx.Err() == nil {
+					// Cancelled because another day already failed the range.
+					continue
+				}
+				available[i], errs[i], fetched[i] = r != nil, err, true
+				if err == circuitbreaker.ErrOpen {
+					abort()
+					if stream != nil {
+						stream.stop()
+					}
+				}
+				if stream != nil {
+					stream.complete(i, r)
+				} else {
+					results[i] = r
+				}
+
+				if err == circuitbreaker.ErrOpen {
+					continue
+				}
+				if err != nil || r == nil {
+					if o.policy == Strict {
+						cancel()
+					} else if err != nil {
+						log.S().Errorf("failed to obtain %s for datetime %s, %+v", f.name, days[i], err)
+					}
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range days {
+		if stream != nil && !stream.acquire(fetchCtx) {
+			break
+		}
+		select {
+		case dayChan <- i:
+		case <-fetchCtx.Done():
+			break dispatch
+		case <-aborted:
+			break dispatch
+		}
+	}
+	close(dayChan)
+	wg.Wait()
+
+	if err := ctx.Err(); err == context.DeadlineExceeded {
+		return []interface{}{}, serviceerror.Wrap(serviceerror.Timeout, err, fmt.Sprintf("timed out fetching %s", f.name))
+	} else if err != nil {
+		return []interface{}{}, err
+	}
+	if stream != nil && stream.err != nil {
+		return []interface{}{}, stream.err
+	}
+	select {
+	case <-aborted:
+		return []interface{}{}, circuitbreaker.ErrOpen
+	default:
+	}
+
+	missing := make([]Missing, 0)
+	readings := make([]interface{}, 0, len(days))
+	for i, r := range results {
+		if available[i] {
+			if r != nil {
+				readings = append(readings, r)
+			}
+		} else if fetched[i] {
+			missing = append(missing, Missing{Date: days[i], Err: errs[i]})
+		}
+	}
+
+	if o.report != nil {
+		*o.report = Report{Policy: o.policy, Missing: missing, Next: next}
+	}
+	if o.policy == Strict && len(missing) > 0 {
+		return []interface{}{}, &Error{Name: f.name, Missing: missing}
+	}
+
+	return readings, nil
+}
@@ -0,0 +1,270 @@
+package rangefetcher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/circuitbreaker"
+	"github.com/svranesevic/charlyedu/serviceerror"
+)
+
+func TestDaysIncludesEndDate(t *testing.T) {
+	days, err := Days(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+	}, days)
+}
+
+func TestDaysReturnsErrorOnStartAfterEnd(t *testing.T) {
+	_, err := Days(time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC), time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NotNil(t, err)
+}
+
+func TestFetchReturnsReadingsInDateOrder(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	readings, err := New("test", 8).Fetch(context.Background(), from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		// Later days finish first, so collection order differs from date order.
+		time.Sleep(time.Duration(31-at.Day()) * time.Millisecond)
+		return at.Day(), nil
+	})
+	assert.Nil(t, err)
+
+	assert.Len(t, readings, 31)
+	for i, r := range readings {
+		assert.Equal(t, i+1, r)
+	}
+}
+
+func failSecondAndMissThirdDay(ctx context.Context, at time.Time) (interface{}, error) {
+	switch at.Day() {
+	case 2:
+		return nil, errUpstream
+	case 3:
+		return nil, nil
+	}
+	return at.Day(), nil
+}
+
+var errUpstream = errors.New("upstream error")
+
+func TestFetchUnderPartialPolicyOmitsAndReportsMissingDays(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC)
+
+	var report Report
+	readings, err := New("test", 2).Fetch(context.Background(), from, to, failSecondAndMissThirdDay, WithPolicy(Partial), ReportTo(&report))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []interface{}{1, 4}, readings)
+	assert.Equal(t, Report{
+		Policy: Partial,
+		Missing: []Missing{
+			{Date: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), Err: errUpstream},
+			{Date: time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)},
+		},
+	}, report)
+}
+
+func TestFetchUnderStrictPolicyReturnsErrorNamingFailedDays(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC)
+
+	_, err := New("test", 1).Fetch(context.Background(), from, to, failSecondAndMissThirdDay)
+
+	rangeErr, ok := err.(*Error)
+	assert.True(t, ok)
+	assert.Equal(t, []Missing{{Date: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), Err: errUpstream}}, rangeErr.Missing)
+	assert.Equal(t, "failed to obtain test for 2019-01-02: upstream error", err.Error())
+	assert.Equal(t, errUpstream, rangeErr.Unwrap())
+}
+
+func TestFetchUnderStrictPolicyReturnsNotFoundForUnavailableDays(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC)
+
+	_, err := New("test", 4).Fetch(context.Background(), from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		if at.Day() == 3 {
+			return nil, nil
+		}
+		return at.Day(), nil
+	})
+
+	assert.Equal(t, serviceerror.NotFound, serviceerror.KindOf(err))
+}
+
+func TestFetchUnderStrictPolicyCancelsRemainingDays(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	var calls int32
+	var mu sync.Mutex
+	cancelled := make(map[time.Time]bool)
+	started := make(chan struct{}, 3)
+
+	_, err := New("test", 4).Fetch(context.Background(), from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+
+		if at.Equal(from) {
+			// Fail only once the three other workers' days are in flight.
+			for i := 0; i < 3; i++ {
+				<-started
+			}
+			return nil, errUpstream
+		}
+
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			cancelled[at] = true
+			mu.Unlock()
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return at, nil
+		}
+	})
+	assert.NotNil(t, err)
+
+	rangeErr := err.(*Error)
+	assert.Equal(t, []Missing{{Date: from, Err: errUpstream}}, rangeErr.Missing)
+	for day := 2; day <= 4; day++ {
+		assert.True(t, cancelled[time.Date(2019, 1, day, 0, 0, 0, 0, time.UTC)], "day %d was left in flight", day)
+	}
+	assert.True(t, atomic.LoadInt32(&calls) < 365)
+}
+
+func TestFetchOptionsOverrideDefaults(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC)
+	fetcher := New("test", 2, WithPolicy(Partial))
+
+	_, err := fetcher.Fetch(context.Background(), from, to, failSecondAndMissThirdDay)
+	assert.Nil(t, err)
+
+	_, err = fetcher.Fetch(context.Background(), from, to, failSecondAndMissThirdDay, WithPolicy(Strict))
+	assert.NotNil(t, err)
+}
+
+func TestFetchNeverExceedsConcurrency(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, concurrency := range []int{1, 3, 8} {
+		for _, streamed := range []bool{false, true} {
+			var inFlight, peak int32
+			// Every call waits for the others until concurrency of them are
+			// in flight, so the peak reaches the bound unless it is lower,
+			// and then holds on long enough for any call past the bound to
+			// start too.
+			full := make(chan struct{})
+			var once sync.Once
+
+			opts := make([]Option, 0)
+			if streamed {
+				opts = append(opts, StreamTo(func(interface{}) error { return nil }))
+			}
+
+			_, err := New("test", concurrency).Fetch(context.Background(), from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+				n := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				if n == int32(concurrency) {
+					once.Do(func() { close(full) })
+				}
+
+				select {
+				case <-full:
+					time.Sleep(time.Millisecond)
+				case <-time.After(time.Second):
+				}
+				return at, nil
+			}, opts...)
+			assert.Nil(t, err)
+
+			assert.Equal(t, int32(concurrency), atomic.LoadInt32(&peak), "concurrency %d, streamed %v", concurrency, streamed)
+		}
+	}
+}
+
+func TestFetchReturnsErrorOnCancelledContext(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+
+	_, err := New("test", 1).Fetch(ctx, from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		calls++
+		cancel()
+		return at, nil
+	})
+
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, calls < 31)
+}
+
+func TestFetchOnlyFetchesDaysOfPage(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 10, 0, 0, 0, 0, time.UTC)
+	fetchDay := func(ctx context.Context, at time.Time) (interface{}, error) {
+		return at.Day(), nil
+	}
+
+	var report Report
+	readings, err := New("test", 2).Fetch(context.Background(), from, to, fetchDay, WithPage(4, 4), ReportTo(&report))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{5, 6, 7, 8}, readings)
+	assert.Equal(t, 8, report.Next)
+
+	readings, err = New("test", 2).Fetch(context.Background(), from, to, fetchDay, WithPage(8, 4), ReportTo(&report))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{9, 10}, readings)
+	assert.Equal(t, 0, report.Next)
+
+	readings, err = New("test", 2).Fetch(context.Background(), from, to, fetchDay, WithPage(12, 4), ReportTo(&report))
+	assert.Nil(t, err)
+	assert.Empty(t, readings)
+	assert.Equal(t, 0, report.Next)
+}
+
+func TestFetchFailsWholeRangeOnOpenCircuitBreakerWithoutCancellingProbe(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	var fetched int32
+	var probeErr error
+	_, err := New("test", 2).Fetch(context.Background(), from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		atomic.AddInt32(&fetched, 1)
+		if at.Day() == 1 {
+			// The day let through by the half-open breaker as its probe.
+			time.Sleep(50 * time.Millisecond)
+			probeErr = ctx.Err()
+			return at.Day(), nil
+		}
+		return nil, circuitbreaker.ErrOpen
+	}, WithPolicy(Partial))
+
+	assert.Equal(t, circuitbreaker.ErrOpen, err)
+	assert.Nil(t, probeErr)
+	assert.True(t, atomic.LoadInt32(&fetched) < 31)
+}
@@ -0,0 +1,87 @@
+package rangefetcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interval is the calendar distance between the points sampled from a
+// range.
+type Interval struct {
+	Years  int
+	Months int
+	Days   int
+}
+
+// Daily samples every day of a range.
+var Daily = Interval{Days: 1}
+
+// ParseInterval parses intervals such as 1d, 7d, 2w, 1mo and 1y. Intervals
+// longer than the supported window, from FirstDay through today, are
+// rejected.
+func ParseInterval(s string) (Interval, error) {
+	return parseInterval(s, time.Now())
+}
+
+func parseInterval(s string, now time.Time) (Interval, error) {
+	units := []struct {
+		suffix   string
+		interval Interval
+	}{
+		{"mo", Interval{Months: 1}},
+		{"d", Interval{Days: 1}},
+		{"w", Interval{Days: 7}},
+		{"y", Interval{Years: 1}},
+	}
+
+	lower := strings.ToLower(s)
+	for _, u := range units {
+		if !strings.HasSuffix(lower, u.suffix) {
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimSuffix(lower, u.suffix))
+		if err != nil || n < 1 {
+			break
+		}
+		// Every unit is at least a day long, so a count past the days of the
+		// window is too long before it is multiplied, and overflows, at all.
+		today := date(now.UTC())
+		if n > int(today.Sub(FirstDay).Hours()/24) {
+			return Daily, tooLong(s, today)
+		}
+		interval := Interval{Years: n * u.interval.Years, Months: n * u.interval.Months, Days: n * u.interval.Days}
+		if interval.after(FirstDay, 1).After(today) {
+			return Daily, tooLong(s, today)
+		}
+		return interval, nil
+	}
+
+	return Daily, fmt.Errorf("invalid interval %q, must be a positive number of days (d), weeks (w), months (mo) or years (y)", s)
+}
+
+func tooLong(s string, today time.Time) error {
+	return fmt.Errorf("interval %q is longer than the supported window of %s through %s",
+		s, FirstDay.Format("2006-01-02"), today.Format("2006-01-02"))
+}
+
+// after returns the n-th point after from. Points are always counted from
+// `from`, and days past the end of a month are clamped to its last day, so
+// that monthly points starting on the 31st stay on the last day of every
+// month rather than drifting.
+func (i Interval) after(from time.Time, n int) time.Time {
+	if i.Years == 0 && i.Months == 0 {
+		return from.AddDate(0, 0, n*i.Days)
+	}
+
+	year, month, day := from.Date()
+	firstOfMonth := time.Date(year, month, 1, from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+	firstOfMonth = firstOfMonth.AddDate(n*i.Years, n*i.Months, 0)
+
+	if last := firstOfMonth.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return firstOfMonth.AddDate(0, 0, day-1+n*i.Days)
+}
@@ -0,0 +1,97 @@
+package rangefetcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterval(t *testing.T) {
+	cases := map[string]Interval{
+		"1d":  {Days: 1},
+		"7d":  {Days: 7},
+		"2w":  {Days: 14},
+		"1mo": {Months: 1},
+		"3MO": {Months: 3},
+		"1y":  {Years: 1},
+	}
+	for s, expected := range cases {
+		interval, err := ParseInterval(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, expected, interval, s)
+	}
+}
+
+func TestParseIntervalReturnsErrorOnInvalidInterval(t *testing.T) {
+	for _, s := range []string{"", "d", "0d", "-1d", "1h", "1.5d", "mo"} {
+		_, err := ParseInterval(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestParseIntervalReturnsErrorOnIntervalLongerThanWindow(t *testing.T) {
+	now := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, s := range []string{"43465d", "6210w", "1429mo", "120y", "9223372036854775807d", "4611686018427387904d", "768614336404564650y"} {
+		_, err := parseInterval(s, now)
+		assert.NotNil(t, err, s)
+	}
+	for _, s := range []string{"43464d", "6209w", "1428mo", "119y"} {
+		_, err := parseInterval(s, now)
+		assert.Nil(t, err, s)
+	}
+}
+
+func TestPointsStopsWhenIntervalWrapsAround(t *testing.T) {
+	points, err := Points(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Interval{Days: 4611686018427387904})
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)}, points)
+}
+
+func TestPointsSamplesEveryInterval(t *testing.T) {
+	points, err := Points(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, 1, 20, 0, 0, 0, 0, time.UTC), Interval{Days: 7})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 8, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 15, 0, 0, 0, 0, time.UTC),
+	}, points)
+}
+
+func TestPointsClampsMonthlyPointsToEndOfMonth(t *testing.T) {
+	points, err := Points(time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2020, 4, 30, 0, 0, 0, 0, time.UTC), Interval{Months: 1})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 4, 30, 0, 0, 0, 0, time.UTC),
+	}, points)
+}
+
+func TestPointsStepsByCalendarDayAcrossDSTTransition(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.Nil(t, err)
+
+	points, err := Points(time.Date(2019, 3, 30, 0, 0, 0, 0, berlin), time.Date(2019, 4, 1, 0, 0, 0, 0, berlin), Daily)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 3, 30, 0, 0, 0, 0, berlin),
+		time.Date(2019, 3, 31, 0, 0, 0, 0, berlin),
+		time.Date(2019, 4, 1, 0, 0, 0, 0, berlin),
+	}, points)
+}
+
+func TestSameWallClockKeepsDateAndClock(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+
+	at := SameWallClock(time.Date(2019, 1, 1, 3, 0, 0, 0, tokyo), time.UTC)
+	assert.Equal(t, time.Date(2019, 1, 1, 3, 0, 0, 0, time.UTC), at)
+	assert.Equal(t, time.Date(2019, 1, 1, 3, 0, 0, 0, tokyo), SameWallClock(at, tokyo))
+}
@@ -0,0 +1,94 @@
+package rangefetcher
+
+import "time"
+
+type options struct {
+	policy    Policy
+	interval  Interval
+	report    *Report
+	stream    func(interface{}) error
+	page      *page
+	clamp     bool
+	maxPoints int
+}
+
+// page is the part of a range which is fetched: limit points starting at the
+// offset-th one.
+type page struct {
+	offset int
+	limit  int
+}
+
+// of returns the page's part of points, and the offset of the next page or
+// zero if there is none.
+func (p *page) of(points []time.Time) ([]time.Time, int) {
+	if p.offset >= len(points) {
+		return points[:0], 0
+	}
+	if p.offset+p.limit >= len(points) {
+		return points[p.offset:], 0
+	}
+	return points[p.offset : p.offset+p.limit], p.offset + p.limit
+}
+
+// Option configures a Fetch. Options passed to New are the defaults for
+// every Fetch, which the options passed to Fetch itself override.
+type Option func(*options)
+
+func WithPolicy(policy Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithInterval samples the range every interval instead of every day.
+func WithInterval(interval Interval) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// WithPage fetches only limit points of the range, starting at the offset-th
+// one. The offset of the following page is reported as Next.
+func WithPage(offset int, limit int) Option {
+	return func(o *options) {
+		o.page = &page{offset: offset, limit: limit}
+	}
+}
+
+// Clamp trims the range to the days the backing services have readings for
+// instead of rejecting it if it reaches past them.
+func Clamp() Option {
+	return func(o *options) {
+		o.clamp = true
+	}
+}
+
+// WithMaxPoints rejects responses of more than max points, the points of a
+// range, or of a page of it, being its days or those it is sampled at. It
+// bounds how many readings a response holds in memory rather than how long a
+// range may be, as longer ranges can still be paged through, sampled or
+// streamed, and streamed ones are not limited at all.
+func WithMaxPoints(max int) Option {
+	return func(o *options) {
+		o.maxPoints = max
+	}
+}
+
+// ReportTo makes Fetch describe in report the policy it applied and the days
+// it could not obtain readings for. The policy is reported before any reading
+// is streamed.
+func ReportTo(report *Report) Option {
+	return func(o *options) {
+		o.report = report
+	}
+}
+
+// StreamTo makes Fetch pass the readings to emit in date order as they become
+// available instead of returning them. An error returned by emit cancels the
+// fetch and is returned by Fetch.
+func StreamTo(emit func(interface{}) error) Option {
+	return func(o *options) {
+		o.stream = emit
+	}
+}
@@ -0,0 +1,88 @@
+package rangefetcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/svranesevic/charlyedu/serviceerror"
+)
+
+// Policy decides what happens to a range when some of its days can not be
+// fetched.
+type Policy int
+
+const (
+	// Strict fails the whole range on the first unavailable day, cancelling
+	// the fetches still in flight.
+	Strict Policy = iota
+	// Partial returns the readings which could be fetched and reports the
+	// days which could not.
+	Partial
+)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch strings.ToLower(s) {
+	case "strict":
+		return Strict, nil
+	case "partial":
+		return Partial, nil
+	}
+	return Strict, fmt.Errorf("unknown policy %q, must be one of strict, partial", s)
+}
+
+func (p Policy) String() string {
+	if p == Partial {
+		return "partial"
+	}
+	return "strict"
+}
+
+// Missing is a day of a range for which no reading was obtained. Err is nil
+// when the backing service simply has no reading for that day.
+type Missing struct {
+	Date time.Time
+	Err  error
+}
+
+// Report describes how a range was fetched.
+type Report struct {
+	Policy  Policy
+	Missing []Missing
+	// Next is the offset of the page following the one fetched, zero if
+	// there is none or the range was not paged.
+	Next int
+}
+
+// Error is returned under the Strict policy when some days of a range could
+// not be fetched.
+type Error struct {
+	Name    string
+	Missing []Missing
+}
+
+func (e *Error) Error() string {
+	const maxListed = 10
+
+	dates := make([]string, 0, maxListed)
+	for i, m := range e.Missing {
+		if i == maxListed {
+			dates = append(dates, fmt.Sprintf("and %d more", len(e.Missing)-maxListed))
+			break
+		}
+		dates = append(dates, m.Date.Format("2006-01-02"))
+	}
+
+	return fmt.Sprintf("failed to obtain %s for %s: %s", e.Name, strings.Join(dates, ", "), e.Unwrap())
+}
+
+// Unwrap returns the first error a day failed with, or
+// serviceerror.ErrNotFound if the days are simply not available.
+func (e *Error) Unwrap() error {
+	for _, m := range e.Missing {
+		if m.Err != nil {
+			return m.Err
+		}
+	}
+	return serviceerror.ErrNotFound
+}
@@ -0,0 +1,85 @@
+package rangefetcher
+
+import (
+	"context"
+	"sync"
+)
+
+// streamWindowPerWorker is how many days each worker may fetch ahead of the
+// last reading streamed.
+const streamWindowPerWorker = 4
+
+// streamer emits the readings of a range in date order, each as soon as
+// every day before it has been fetched. At most window days may be fetched
+// ahead of the last one emitted, so a slow consumer holds the fetches back
+// rather than having readings pile up in memory.
+type streamer struct {
+	emit   func(interface{}) error
+	policy Policy
+	cancel context.CancelFunc
+	window chan struct{}
+
+	mu      sync.Mutex
+	next    int
+	done    []bool
+	results []interface{}
+	stopped bool
+	err     error
+}
+
+func newStreamer(emit func(interface{}) error, days int, window int, policy Policy, cancel context.CancelFunc) *streamer {
+	return &streamer{
+		emit:    emit,
+		policy:  policy,
+		cancel:  cancel,
+		window:  make(chan struct{}, window),
+		done:    make([]bool, days),
+		results: make([]interface{}, days),
+	}
+}
+
+// acquire blocks until another day may be fetched. It returns false if ctx
+// is done first.
+func (s *streamer) acquire(ctx context.Context) bool {
+	select {
+	case s.window <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// complete records the reading for day i, nil if there is none, and emits
+// every reading it completes the fetched prefix of the range with. Under the
+// Strict policy nothing is emitted past a missing day.
+func (s *streamer) complete(i int, r interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.done[i], s.results[i] = true, r
+	for s.next < len(s.done) && s.done[s.next] {
+		r := s.results[s.next]
+		s.results[s.next] = nil
+		s.next++
+
+		switch {
+		case s.stopped:
+		case r == nil:
+			s.stopped = s.policy == Strict
+		default:
+			if err := s.emit(r); err != nil {
+				s.stopped, s.err = true, err
+				s.cancel()
+			}
+		}
+		<-s.window
+	}
+}
+
+// stop makes the streamer emit nothing more.
+func (s *streamer) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+}
@@ -0,0 +1,104 @@
+package rangefetcher
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchStreamsReadingsInDateOrder(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	streamed := make([]interface{}, 0)
+	readings, err := New("test", 8).Fetch(context.Background(), from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		time.Sleep(time.Duration(31-at.Day()) * time.Millisecond)
+		return at.Day(), nil
+	}, StreamTo(func(r interface{}) error {
+		streamed = append(streamed, r)
+		return nil
+	}))
+	assert.Nil(t, err)
+
+	assert.Empty(t, readings)
+	assert.Len(t, streamed, 31)
+	for i, r := range streamed {
+		assert.Equal(t, i+1, r)
+	}
+}
+
+func TestFetchStreamDoesNotFetchFarAheadOfSlowConsumer(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	var fetched, emitted, maxAhead int32
+	_, err := New("test", 2).Fetch(context.Background(), from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		ahead := atomic.AddInt32(&fetched, 1) - atomic.LoadInt32(&emitted)
+		for {
+			max := atomic.LoadInt32(&maxAhead)
+			if ahead <= max || atomic.CompareAndSwapInt32(&maxAhead, max, ahead) {
+				break
+			}
+		}
+		return at, nil
+	}, StreamTo(func(r interface{}) error {
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&emitted, 1)
+		return nil
+	}))
+	assert.Nil(t, err)
+
+	assert.Equal(t, int32(60), atomic.LoadInt32(&emitted))
+	assert.True(t, atomic.LoadInt32(&maxAhead) <= 2*streamWindowPerWorker)
+}
+
+func TestFetchStreamUnderStrictPolicyStopsAtMissingDay(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	streamed := make([]interface{}, 0)
+	_, err := New("test", 1).Fetch(context.Background(), from, to, failSecondAndMissThirdDay, StreamTo(func(r interface{}) error {
+		streamed = append(streamed, r)
+		return nil
+	}))
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []interface{}{1}, streamed)
+}
+
+func TestFetchStreamUnderPartialPolicySkipsMissingDays(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	var report Report
+	streamed := make([]interface{}, 0)
+	_, err := New("test", 2).Fetch(context.Background(), from, to, failSecondAndMissThirdDay, WithPolicy(Partial), ReportTo(&report), StreamTo(func(r interface{}) error {
+		streamed = append(streamed, r)
+		return nil
+	}))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 4, 5}, streamed)
+	assert.Len(t, report.Missing, 2)
+}
+
+func TestFetchStreamReturnsEmitError(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	errGone := errors.New("client went away")
+	var calls int32
+	_, err := New("test", 4).Fetch(context.Background(), from, to, func(ctx context.Context, at time.Time) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return at, nil
+	}, StreamTo(func(r interface{}) error {
+		return errGone
+	}))
+
+	assert.Equal(t, errGone, err)
+	assert.True(t, atomic.LoadInt32(&calls) < 365)
+}
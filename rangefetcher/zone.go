@@ -0,0 +1,14 @@
+package rangefetcher
+
+import "time"
+
+// SameWallClock returns the time in loc which has the same date and clock
+// reading as t. The backing services key their daily readings by UTC date, so
+// the points of a range in any other zone are passed to them as
+// SameWallClock(at, time.UTC), and the readings they return are rendered back
+// with SameWallClock(date, at.Location()).
+func SameWallClock(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	return time.Date(year, month, day, hour, min, sec, t.Nanosecond(), loc)
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -13,18 +14,29 @@ import (
 	"net/http"
 )
 
-func New(ts temperatureservice.Service, wss windspeedservice.Service, ws weatherservice.Service) *mux.Router {
+// streamingRoutes names the routes whose responses may be streamed.
+var streamingRoutes = map[string]bool{
+	"GetTemperature": true,
+	"GetWindSpeed":   true,
+	"GetWeather":     true,
+}
+
+// New returns the router of the service's endpoints. Requests time out after
+// 10 seconds, and streamed ones, which last as long as their client keeps
+// reading them, after streamTimeout.
+func New(ts temperatureservice.Service, wss windspeedservice.Service, ws weatherservice.Service, streamTimeout time.Duration) *mux.Router {
 	router := mux.NewRouter()
 
 	router.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Add("Content-Type", "application/json")
-			next.ServeHTTP(w, r)
-		})
-	})
-	router.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+			timeout := 10 * time.Second
+			if route := mux.CurrentRoute(r); route != nil && streamingRoutes[route.GetName()] {
+				if stream, _ := strconv.ParseBool(r.FormValue("stream")); stream {
+					timeout = streamTimeout
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
 			defer cancel()
 
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -41,35 +53,53 @@ func New(ts temperatureservice.Service, wss windspeedservice.Service, ws weather
 func initializeTemperatureRoutes(ts temperatureservice.Service, router *mux.Router) {
 	router.
 		Path("/temperatures").
-		Queries("start", "{start}").
-		Queries("end", "{end}").
 		Methods("GET").
 		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			handler.GetTemperature(ts, w, r)
 		}).
 		Name("GetTemperature")
+
+	router.
+		Path("/temperatures/stats").
+		Methods("GET").
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.GetTemperatureStats(ts, w, r)
+		}).
+		Name("GetTemperatureStats")
 }
 
 func initializeWindSpeedRoutes(wss windspeedservice.Service, router *mux.Router) {
 	router.
 		Path("/speeds").
-		Queries("start", "{start}").
-		Queries("end", "{end}").
 		Methods("GET").
 		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			handler.GetWindSpeed(wss, w, r)
 		}).
 		Name("GetWindSpeed")
+
+	router.
+		Path("/speeds/stats").
+		Methods("GET").
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.GetWindSpeedStats(wss, w, r)
+		}).
+		Name("GetWindSpeedStats")
 }
 
 func initializeWeatherRoutes(ws weatherservice.Service, router *mux.Router) {
 	router.
 		Path("/weather").
-		Queries("start", "{start}").
-		Queries("end", "{end}").
 		Methods("GET").
 		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			handler.GetWeather(ws, w, r)
 		}).
 		Name("GetWeather")
+
+	router.
+		Path("/weather/stats").
+		Methods("GET").
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.GetWeatherStats(ws, w, r)
+		}).
+		Name("GetWeatherStats")
 }
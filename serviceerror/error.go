@@ -0,0 +1,86 @@
+package serviceerror
+
+// Kind classifies what went wrong while serving a request, independently of
+// which service it went wrong in.
+type Kind int
+
+const (
+	Internal Kind = iota
+	InvalidRange
+	OutOfBounds
+	NotFound
+	Unavailable
+	Timeout
+	MalformedPayload
+)
+
+func (k Kind) String() string {
+	switch k {
+	case InvalidRange:
+		return "invalid range"
+	case OutOfBounds:
+		return "out of bounds"
+	case NotFound:
+		return "not found"
+	case Unavailable:
+		return "unavailable"
+	case Timeout:
+		return "timeout"
+	case MalformedPayload:
+		return "malformed payload"
+	}
+	return "internal"
+}
+
+var (
+	ErrInvalidRange     = New(InvalidRange, "`start` must be before `end`")
+	ErrOutOfBounds      = New(OutOfBounds, "date is outside of the supported range")
+	ErrNotFound         = New(NotFound, "reading is not available")
+	ErrUnavailable      = New(Unavailable, "upstream service is unavailable")
+	ErrTimeout          = New(Timeout, "upstream service timed out")
+	ErrMalformedPayload = New(MalformedPayload, "upstream service returned a malformed payload")
+)
+
+// Error is an error of a known Kind, optionally wrapping its cause.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func Wrap(kind Kind, err error, message string) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the Kind of the first *Error found by unwrapping err, or
+// Internal if there is none.
+func KindOf(err error) Kind {
+	for err != nil {
+		if e, ok := err.(*Error); ok {
+			return e.Kind
+		}
+
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+
+	return Internal
+}
@@ -0,0 +1,44 @@
+package serviceerror
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type wrappingError struct {
+	err error
+}
+
+func (e wrappingError) Error() string {
+	return "wrapped: " + e.err.Error()
+}
+
+func (e wrappingError) Unwrap() error {
+	return e.err
+}
+
+func TestKindOfReturnsKindOfError(t *testing.T) {
+	assert.Equal(t, InvalidRange, KindOf(ErrInvalidRange))
+	assert.Equal(t, Timeout, KindOf(Wrap(Timeout, context.DeadlineExceeded, "timed out")))
+}
+
+func TestKindOfUnwrapsErrors(t *testing.T) {
+	err := wrappingError{err: Wrap(Unavailable, errors.New("connection refused"), "unavailable")}
+
+	assert.Equal(t, Unavailable, KindOf(err))
+}
+
+func TestKindOfReturnsInternalForUnknownErrors(t *testing.T) {
+	assert.Equal(t, Internal, KindOf(errors.New("unknown")))
+	assert.Equal(t, Internal, KindOf(wrappingError{err: errors.New("unknown")}))
+	assert.Equal(t, Internal, KindOf(nil))
+}
+
+func TestErrorIncludesCause(t *testing.T) {
+	err := Wrap(MalformedPayload, errors.New("unexpected EOF"), "malformed payload")
+
+	assert.Equal(t, "malformed payload: unexpected EOF", err.Error())
+}
@@ -0,0 +1,47 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bucket is the calendar period readings are grouped by.
+type Bucket int
+
+const (
+	Day Bucket = iota
+	// Week is an ISO 8601 week, which starts on Monday.
+	Week
+	Month
+	Year
+)
+
+func ParseBucket(s string) (Bucket, error) {
+	switch strings.ToLower(s) {
+	case "day":
+		return Day, nil
+	case "week":
+		return Week, nil
+	case "month":
+		return Month, nil
+	case "year":
+		return Year, nil
+	}
+	return Day, fmt.Errorf("unknown bucket %q, must be one of day, week, month, year", s)
+}
+
+// Start returns the start of the bucket t falls into, in t's location.
+func (b Bucket) Start(t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch b {
+	case Week:
+		// time.Weekday counts from Sunday, ISO weeks from Monday.
+		return time.Date(year, month, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case Month:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case Year:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
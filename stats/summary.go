@@ -0,0 +1,106 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Summary describes the distribution of a set of values. StdDev is the
+// population standard deviation. Percentiles are keyed by their rank, as in
+// p25 or p99.9, and interpolated linearly between the closest ranks.
+type Summary struct {
+	Count       int                `json:"count"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Mean        float64            `json:"mean"`
+	Median      float64            `json:"median"`
+	StdDev      float64            `json:"stddev"`
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+// Observation holds the values of the fields of a reading taken at Date.
+type Observation struct {
+	Date   time.Time
+	Values map[string]float64
+}
+
+// BucketSummary summarizes every field of the readings in the bucket which
+// starts at Start.
+type BucketSummary struct {
+	Start time.Time          `json:"start"`
+	Stats map[string]Summary `json:"stats"`
+}
+
+// Summarize describes values, computing the given percentiles, each between
+// 0 and 100.
+func Summarize(values []float64, percentiles []float64) Summary {
+	s := Summary{Count: len(values), Percentiles: make(map[string]float64, len(percentiles))}
+	if len(values) == 0 {
+		return s
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	s.Mean = sum / float64(len(sorted))
+
+	var squares float64
+	for _, v := range sorted {
+		squares += (v - s.Mean) * (v - s.Mean)
+	}
+	s.StdDev = math.Sqrt(squares / float64(len(sorted)))
+
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	s.Median = percentile(sorted, 50)
+	for _, p := range percentiles {
+		s.Percentiles["p"+strconv.FormatFloat(p, 'f', -1, 64)] = percentile(sorted, p)
+	}
+
+	return s
+}
+
+// Aggregate groups observations, which must be in date order, into buckets
+// and summarizes every field within each of them.
+func Aggregate(observations []Observation, bucket Bucket, percentiles []float64) []BucketSummary {
+	summaries := make([]BucketSummary, 0)
+
+	for i := 0; i < len(observations); {
+		start := bucket.Start(observations[i].Date)
+
+		values := make(map[string][]float64)
+		for ; i < len(observations) && bucket.Start(observations[i].Date).Equal(start); i++ {
+			for field, v := range observations[i].Values {
+				values[field] = append(values[field], v)
+			}
+		}
+
+		s := BucketSummary{Start: start, Stats: make(map[string]Summary, len(values))}
+		for field, vs := range values {
+			s.Stats[field] = Summarize(vs, percentiles)
+		}
+		summaries = append(summaries, s)
+	}
+
+	return summaries
+}
+
+// percentile returns the p-th percentile of sorted, which must not be empty.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	if lower < 0 {
+		return sorted[0]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]float64{4, 1, 3, 2, 5}, []float64{25, 90})
+
+	assert.Equal(t, 5, s.Count)
+	assert.Equal(t, 1.0, s.Min)
+	assert.Equal(t, 5.0, s.Max)
+	assert.Equal(t, 3.0, s.Mean)
+	assert.Equal(t, 3.0, s.Median)
+	assert.InDelta(t, 1.41421, s.StdDev, 0.00001)
+	assert.Equal(t, map[string]float64{"p25": 2, "p90": 4.6}, s.Percentiles)
+}
+
+func TestSummarizeInterpolatesMedianOfEvenCount(t *testing.T) {
+	s := Summarize([]float64{1, 2, 3, 4}, nil)
+
+	assert.Equal(t, 2.5, s.Median)
+}
+
+func TestSummarizeOfNoValues(t *testing.T) {
+	s := Summarize(nil, []float64{50})
+
+	assert.Equal(t, Summary{Percentiles: map[string]float64{}}, s)
+}
+
+func TestAggregateGroupsByISOWeek(t *testing.T) {
+	observations := make([]Observation, 0)
+	// 2019-01-06 is a Sunday and 2019-01-07 a Monday.
+	for day := 5; day <= 8; day++ {
+		observations = append(observations, Observation{
+			Date:   time.Date(2019, 1, day, 0, 0, 0, 0, time.UTC),
+			Values: map[string]float64{"temp": float64(day)},
+		})
+	}
+
+	summaries := Aggregate(observations, Week, nil)
+
+	assert.Len(t, summaries, 2)
+	assert.Equal(t, time.Date(2018, 12, 31, 0, 0, 0, 0, time.UTC), summaries[0].Start)
+	assert.Equal(t, 2, summaries[0].Stats["temp"].Count)
+	assert.Equal(t, 5.5, summaries[0].Stats["temp"].Mean)
+	assert.Equal(t, time.Date(2019, 1, 7, 0, 0, 0, 0, time.UTC), summaries[1].Start)
+	assert.Equal(t, 7.5, summaries[1].Stats["temp"].Mean)
+}
+
+func TestBucketStart(t *testing.T) {
+	at := time.Date(2019, 8, 15, 13, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2019, 8, 15, 0, 0, 0, 0, time.UTC), Day.Start(at))
+	assert.Equal(t, time.Date(2019, 8, 12, 0, 0, 0, 0, time.UTC), Week.Start(at))
+	assert.Equal(t, time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC), Month.Start(at))
+	assert.Equal(t, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), Year.Start(at))
+}
+
+func TestParseBucketReturnsErrorOnUnknownBucket(t *testing.T) {
+	_, err := ParseBucket("fortnight")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/svranesevic/charlyedu/cache"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store persists readings in an embedded database file, keyed by UTC date,
+// with one bucket per kind of reading.
+type Store struct {
+	db *bolt.DB
+}
+
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// key returns the key of the reading for the UTC day of at. Keys sort in date
+// order.
+func key(at time.Time) []byte {
+	return []byte(cache.DayKey(at))
+}
+
+// Get decodes the reading stored in bucket for the UTC day of at into v. It
+// returns false if there is none.
+func (s *Store) Get(bucket string, at time.Time, v interface{}) (bool, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(bucket)); b != nil {
+			if stored := b.Get(key(at)); stored != nil {
+				value = append([]byte{}, stored...)
+			}
+		}
+		return nil
+	})
+	if err != nil || value == nil {
+		return false, err
+	}
+
+	if err = json.Unmarshal(value, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Put stores v in bucket as the reading for the UTC day of at. Concurrent
+// calls are batched into a single transaction.
+func (s *Store) Put(bucket string, at time.Time, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Batch(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return b.Put(key(at), value)
+	})
+}
+
+const checkpointBucket = "checkpoints"
+
+// Checkpoint is a date persisted under a name, recording how far a job got.
+type Checkpoint struct {
+	store *Store
+	name  string
+}
+
+func (s *Store) Checkpoint(name string) Checkpoint {
+	return Checkpoint{store: s, name: name}
+}
+
+func (c Checkpoint) Load() (time.Time, bool, error) {
+	var value []byte
+	err := c.store.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(checkpointBucket)); b != nil {
+			value = append([]byte{}, b.Get([]byte(c.name))...)
+		}
+		return nil
+	})
+	if err != nil || len(value) == 0 {
+		return time.Time{}, false, err
+	}
+
+	at, err := time.Parse("2006-01-02", string(value))
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return at, true, nil
+}
+
+func (c Checkpoint) Save(at time.Time) error {
+	return c.store.db.Batch(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(checkpointBucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(c.name), key(at))
+	})
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reading struct {
+	Temperature float64   `json:"temp"`
+	Date        time.Time `json:"date"`
+}
+
+func openTestStore(t *testing.T) (*Store, string, func()) {
+	dir, err := ioutil.TempDir("", "store")
+	assert.Nil(t, err)
+
+	path := filepath.Join(dir, "readings.db")
+	s, err := Open(path)
+	assert.Nil(t, err)
+
+	return s, path, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestGetReturnsStoredReading(t *testing.T) {
+	s, _, cleanup := openTestStore(t)
+	defer cleanup()
+
+	stored := reading{Temperature: 10.5, Date: time.Date(2018, 8, 12, 0, 0, 0, 0, time.UTC)}
+	assert.Nil(t, s.Put("temperature", time.Date(2018, 8, 12, 12, 0, 0, 0, time.UTC), stored))
+
+	var r reading
+	found, err := s.Get("temperature", time.Date(2018, 8, 12, 18, 0, 0, 0, time.UTC), &r)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, stored, r)
+}
+
+func TestGetReturnsNotFoundForMissingReading(t *testing.T) {
+	s, _, cleanup := openTestStore(t)
+	defer cleanup()
+
+	var r reading
+	found, err := s.Get("temperature", time.Date(2018, 8, 12, 0, 0, 0, 0, time.UTC), &r)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	assert.Nil(t, s.Put("temperature", time.Date(2018, 8, 12, 0, 0, 0, 0, time.UTC), reading{}))
+
+	found, err = s.Get("temperature", time.Date(2018, 8, 13, 0, 0, 0, 0, time.UTC), &r)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	found, err = s.Get("wind speed", time.Date(2018, 8, 12, 0, 0, 0, 0, time.UTC), &r)
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestReadingsSurviveReopening(t *testing.T) {
+	s, path, cleanup := openTestStore(t)
+	defer cleanup()
+
+	stored := reading{Temperature: 10.5, Date: time.Date(2018, 8, 12, 0, 0, 0, 0, time.UTC)}
+	assert.Nil(t, s.Put("temperature", stored.Date, stored))
+	assert.Nil(t, s.Close())
+
+	s, err := Open(path)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	var r reading
+	found, err := s.Get("temperature", stored.Date, &r)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, stored, r)
+}
+
+func TestCheckpointReturnsSavedDate(t *testing.T) {
+	s, _, cleanup := openTestStore(t)
+	defer cleanup()
+
+	checkpoint := s.Checkpoint("warmer")
+	_, ok, err := checkpoint.Load()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, checkpoint.Save(time.Date(1927, 5, 1, 12, 0, 0, 0, time.UTC)))
+
+	at, ok, err := checkpoint.Load()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(1927, 5, 1, 0, 0, 0, 0, time.UTC), at)
+}
@@ -3,95 +3,76 @@ package temperatureservice
 import (
 	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
-	"io/ioutil"
-	"math"
-	"net/http"
-	"sort"
-	"strings"
-	"sync"
 	"time"
 
-	log "go.uber.org/zap"
+	"github.com/svranesevic/charlyedu/daysource"
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	"github.com/svranesevic/charlyedu/store"
+	"github.com/svranesevic/charlyedu/upstream"
 )
 
-type temperatureService struct {
-	host string
-}
-
 type Service interface {
-	GetForRange(ctx context.Context, from time.Time, to time.Time) ([]Temperature, error)
+	GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]Temperature, error)
 	GetForDateTime(ctx context.Context, at time.Time) (*Temperature, error)
 }
 
-func New(host string) Service {
-	if strings.HasSuffix(host, "/") {
-		host = strings.TrimSuffix(host, "/")
-	}
-	return temperatureService{host: host}
-}
-
-func (ts temperatureService) GetForRange(ctx context.Context, from time.Time, to time.Time) ([]Temperature, error) {
-	if from.After(to) {
-		return []Temperature{}, errors.New("`start` must be before `end`")
-	}
-	to = to.Add(24 * time.Hour)
+const storeBucket = "temperature"
 
-	numDays := int(math.Ceil(to.Sub(from).Hours() / 24))
-	tempChan := make(chan Temperature, numDays)
-	var wg sync.WaitGroup
+// New returns a Source obtaining temperature readings from the backing service
+// at host.
+func New(host string, opts ...upstream.Option) daysource.Source {
+	return daysource.Upstream(upstream.New(host, opts...), decode)
+}
 
-	for at := from; at.Before(to); at = at.Add(24 * time.Hour) {
-		wg.Add(1)
-		go func(at time.Time) {
-			defer wg.Done()
+// WithStore looks temperature readings up in st before obtaining them through
+// s, and persists the readings of past days obtained through s in st.
+func WithStore(s daysource.Source, st *store.Store) daysource.Source {
+	return daysource.WithStore(s, st, storeBucket, decode)
+}
 
-			if t, err := ts.GetForDateTime(ctx, at); err != nil {
-				log.S().Errorf("failed to obtain temperature for datetime %s, %+v", at, err)
-			} else if t != nil {
-				tempChan <- *t
-			}
-		}(at)
-	}
-	wg.Wait()
-	close(tempChan)
+func decode(payload []byte) (interface{}, error) {
+	var r Temperature
+	err := json.Unmarshal(payload, &r)
+	return r, err
+}
 
-	temps := make(temperaturesSlice, 0)
-	for t := range tempChan {
-		temps = append(temps, t)
-	}
+type temperatureService struct {
+	source  daysource.Source
+	fetcher rangefetcher.Fetcher
+}
 
-	sort.Sort(temps)
-	return temps, nil
+// NewService serves temperature readings out of s, fetching each day of a
+// range through fetcher.
+func NewService(s daysource.Source, fetcher rangefetcher.Fetcher) Service {
+	return temperatureService{source: s, fetcher: fetcher}
 }
 
 func (ts temperatureService) GetForDateTime(ctx context.Context, at time.Time) (*Temperature, error) {
-	url := fmt.Sprintf("%s/?at=%s", ts.host, at.Format("2006-01-02T15:04:05Z0700"))
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req = req.WithContext(ctx)
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
+	r, err := ts.source.GetForDateTime(ctx, at)
+	if err != nil || r == nil {
 		return nil, err
 	}
 
-	if res.StatusCode == http.StatusNotFound {
-		return nil, nil
-	}
+	temp := r.(Temperature)
+	return &temp, nil
+}
 
-	bodyBytes, err := ioutil.ReadAll(res.Body)
+func (ts temperatureService) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]Temperature, error) {
+	results, err := ts.fetcher.FetchDays(ctx, from, to, func(ctx context.Context, at time.Time) (rangefetcher.Dated, error) {
+		r, err := ts.source.GetForDateTime(ctx, at)
+		if r == nil {
+			return nil, err
+		}
+		return r.(Temperature), err
+	}, opts...)
 	if err != nil {
-		return nil, err
+		return []Temperature{}, err
 	}
 
-	var t Temperature
-	if err = json.Unmarshal(bodyBytes, &t); err != nil {
-		return nil, err
+	temps := make([]Temperature, len(results))
+	for i, r := range results {
+		temps[i] = r.(Temperature)
 	}
 
-	return &t, nil
+	return temps, nil
 }
@@ -0,0 +1,42 @@
+package temperatureservice
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/rangefetcher"
+)
+
+// countingSource counts the readings obtained through it, which are available
+// for every day.
+type countingSource struct {
+	calls int32
+}
+
+func (s *countingSource) GetForDateTime(ctx context.Context, at time.Time) (interface{}, error) {
+	atomic.AddInt32(&s.calls, 1)
+	return Temperature{Temperature: 1.5, Date: at}, nil
+}
+
+func (s *countingSource) count() int32 {
+	return atomic.LoadInt32(&s.calls)
+}
+
+func TestGetForRangeFetchesEveryDayOfRangeInItsLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+
+	src := &countingSource{}
+	readings, err := NewService(src, rangefetcher.New("test", 2)).GetForRange(context.Background(), time.Date(2019, 1, 1, 0, 0, 0, 0, tokyo), time.Date(2019, 1, 3, 0, 0, 0, 0, tokyo))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []Temperature{
+		{Temperature: 1.5, Date: time.Date(2019, 1, 1, 0, 0, 0, 0, tokyo)},
+		{Temperature: 1.5, Date: time.Date(2019, 1, 2, 0, 0, 0, 0, tokyo)},
+		{Temperature: 1.5, Date: time.Date(2019, 1, 3, 0, 0, 0, 0, tokyo)},
+	}, readings)
+	assert.Equal(t, int32(3), src.count())
+}
@@ -1,22 +1,18 @@
 package temperatureservice
 
-import "time"
+import (
+	"time"
+
+	"github.com/svranesevic/charlyedu/rangefetcher"
+)
 
 type Temperature struct {
 	Temperature float64   `json:"temp"`
 	Date        time.Time `json:"date"`
 }
 
-type temperaturesSlice []Temperature
-
-func (t temperaturesSlice) Len() int {
-	return len(t)
-}
-
-func (t temperaturesSlice) Less(i, j int) bool {
-	return t[i].Date.Before(t[j].Date)
-}
-
-func (t temperaturesSlice) Swap(i, j int) {
-	t[i], t[j] = t[j], t[i]
+// InLocation returns the reading dated at the same wall clock in loc.
+func (t Temperature) InLocation(loc *time.Location) rangefetcher.Dated {
+	t.Date = rangefetcher.SameWallClock(t.Date, loc)
+	return t
 }
@@ -0,0 +1,101 @@
+package units
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TemperatureUnit is a unit temperatures are given in. The backing services
+// give them in degrees Celsius.
+type TemperatureUnit string
+
+const (
+	Celsius    TemperatureUnit = "celsius"
+	Fahrenheit TemperatureUnit = "fahrenheit"
+	Kelvin     TemperatureUnit = "kelvin"
+)
+
+// SpeedUnit is a unit speeds are given in. The backing services give them in
+// meters per second.
+type SpeedUnit string
+
+const (
+	MetersPerSecond   SpeedUnit = "m/s"
+	KilometersPerHour SpeedUnit = "km/h"
+	MilesPerHour      SpeedUnit = "mph"
+	Knots             SpeedUnit = "kn"
+)
+
+// Units are the units readings are given in.
+type Units struct {
+	Temperature TemperatureUnit `json:"temperature"`
+	Speed       SpeedUnit       `json:"speed"`
+}
+
+// Metric are the units of the backing services.
+var Metric = Units{Temperature: Celsius, Speed: MetersPerSecond}
+
+// ParseSystem parses a system of units: metric, imperial, si or aviation.
+func ParseSystem(s string) (Units, error) {
+	switch strings.ToLower(s) {
+	case "metric":
+		return Metric, nil
+	case "imperial":
+		return Units{Temperature: Fahrenheit, Speed: MilesPerHour}, nil
+	case "si":
+		return Units{Temperature: Kelvin, Speed: MetersPerSecond}, nil
+	case "aviation":
+		return Units{Temperature: Celsius, Speed: Knots}, nil
+	}
+	return Metric, fmt.Errorf("unknown units %q, must be one of metric, imperial, si, aviation", s)
+}
+
+func ParseTemperatureUnit(s string) (TemperatureUnit, error) {
+	switch strings.ToLower(s) {
+	case "c", "celsius":
+		return Celsius, nil
+	case "f", "fahrenheit":
+		return Fahrenheit, nil
+	case "k", "kelvin":
+		return Kelvin, nil
+	}
+	return Celsius, fmt.Errorf("unknown temperature unit %q, must be one of c, f, k", s)
+}
+
+func ParseSpeedUnit(s string) (SpeedUnit, error) {
+	switch strings.ToLower(s) {
+	case "ms", "m/s", "mps":
+		return MetersPerSecond, nil
+	case "kmh", "km/h", "kph":
+		return KilometersPerHour, nil
+	case "mph":
+		return MilesPerHour, nil
+	case "kn", "kt", "knots":
+		return Knots, nil
+	}
+	return MetersPerSecond, fmt.Errorf("unknown speed unit %q, must be one of ms, kmh, mph, kn", s)
+}
+
+// FromCelsius converts a temperature in degrees Celsius to u.
+func (u TemperatureUnit) FromCelsius(c float64) float64 {
+	switch u {
+	case Fahrenheit:
+		return c*9/5 + 32
+	case Kelvin:
+		return c + 273.15
+	}
+	return c
+}
+
+// FromMetersPerSecond converts a speed in meters per second to u.
+func (u SpeedUnit) FromMetersPerSecond(v float64) float64 {
+	switch u {
+	case KilometersPerHour:
+		return v * 3.6
+	case MilesPerHour:
+		return v * 3600 / 1609.344
+	case Knots:
+		return v * 3600 / 1852
+	}
+	return v
+}
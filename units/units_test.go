@@ -0,0 +1,39 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromCelsius(t *testing.T) {
+	assert.Equal(t, 10.0, Celsius.FromCelsius(10))
+	assert.Equal(t, 50.0, Fahrenheit.FromCelsius(10))
+	assert.Equal(t, 283.15, Kelvin.FromCelsius(10))
+}
+
+func TestFromMetersPerSecond(t *testing.T) {
+	assert.Equal(t, 10.0, MetersPerSecond.FromMetersPerSecond(10))
+	assert.Equal(t, 36.0, KilometersPerHour.FromMetersPerSecond(10))
+	assert.InDelta(t, 22.3694, MilesPerHour.FromMetersPerSecond(10), 0.0001)
+	assert.InDelta(t, 19.4384, Knots.FromMetersPerSecond(10), 0.0001)
+}
+
+func TestParseSystem(t *testing.T) {
+	u, err := ParseSystem("Imperial")
+	assert.Nil(t, err)
+	assert.Equal(t, Units{Temperature: Fahrenheit, Speed: MilesPerHour}, u)
+
+	_, err = ParseSystem("cubits")
+	assert.NotNil(t, err)
+}
+
+func TestParseUnitsAcceptAbbreviations(t *testing.T) {
+	temp, err := ParseTemperatureUnit("K")
+	assert.Nil(t, err)
+	assert.Equal(t, Kelvin, temp)
+
+	speed, err := ParseSpeedUnit("kt")
+	assert.Nil(t, err)
+	assert.Equal(t, Knots, speed)
+}
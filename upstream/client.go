@@ -0,0 +1,177 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/svranesevic/charlyedu/serviceerror"
+)
+
+// Client fetches single readings from one of the backing services.
+type Client struct {
+	host        string
+	httpClient  *http.Client
+	transport   http.RoundTripper
+	headers     http.Header
+	timeout     time.Duration
+	retry       RetryPolicy
+	flights     *flightGroup
+	limiter     *tokenBucket
+	hedger      *hedger
+	concurrency *ConcurrencyLimiter
+}
+
+func New(host string, opts ...Option) *Client {
+	if strings.HasSuffix(host, "/") {
+		host = strings.TrimSuffix(host, "/")
+	}
+
+	c := &Client{
+		host:       host,
+		httpClient: http.DefaultClient,
+		headers:    make(http.Header),
+		retry:      NoRetry,
+		flights:    newFlightGroup(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.transport != nil {
+		httpClient := *c.httpClient
+		httpClient.Transport = c.transport
+		c.httpClient = &httpClient
+	}
+	if c.retry.MaxAttempts < 1 {
+		c.retry.MaxAttempts = 1
+	}
+
+	return c
+}
+
+// Get decodes the reading at the given datetime into v, retrying failed
+// attempts according to the client's RetryPolicy and hedging slow ones
+// according to its HedgePolicy. It returns false when the backing service has
+// no reading for that datetime. Concurrent calls for the same day share a
+// single request.
+func (c *Client) Get(ctx context.Context, at time.Time, v interface{}) (bool, error) {
+	u := fmt.Sprintf("%s/?at=%s", c.host, url.QueryEscape(at.Format("2006-01-02T15:04:05Z0700")))
+
+	body, found, err := c.flights.do(ctx, at.UTC().Format("2006-01-02"), func(ctx context.Context) ([]byte, bool, error) {
+		return c.fetch(ctx, u)
+	})
+	if err != nil || !found {
+		return false, err
+	}
+
+	if err = json.Unmarshal(body, v); err != nil {
+		return false, serviceerror.Wrap(serviceerror.MalformedPayload, err, fmt.Sprintf("malformed payload from %s", u))
+	}
+	return true, nil
+}
+
+func (c *Client) fetch(ctx context.Context, u string) ([]byte, bool, error) {
+	get := c.get
+	if c.hedger != nil {
+		get = c.hedgedGet
+	}
+
+	for attempt := 1; ; attempt++ {
+		body, found, retryable, err := get(ctx, u)
+		if err == nil {
+			return body, found, nil
+		}
+
+		if !retryable || attempt >= c.retry.MaxAttempts || !c.retry.wait(ctx, attempt) {
+			return nil, false, err
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, u string) (body []byte, found bool, retryable bool, err error) {
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, false, false, err
+		}
+	}
+	if c.concurrency != nil {
+		start, acquireErr := c.concurrency.acquire(ctx)
+		if acquireErr != nil {
+			return nil, false, false, acquireErr
+		}
+		defer func() {
+			c.concurrency.release(ctx, start, err)
+		}()
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, false, false, err
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+
+	attemptCtx := ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req = req.WithContext(attemptCtx)
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, false, ctx.Err() == nil, requestError(attemptCtx, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, false, false, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, false, c.retry.isRetryableStatus(res.StatusCode), statusError(res.StatusCode, u)
+	}
+
+	body, err = ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, false, ctx.Err() == nil, requestError(attemptCtx, err)
+	}
+
+	return body, true, false, nil
+}
+
+// requestError classifies an error which occurred while talking to the
+// backing service.
+func requestError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return serviceerror.Wrap(serviceerror.Timeout, err, "upstream service timed out")
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return serviceerror.Wrap(serviceerror.Timeout, err, "upstream service timed out")
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+	return serviceerror.Wrap(serviceerror.Unavailable, err, "upstream service is unavailable")
+}
+
+// statusError classifies an unexpected status returned by the backing
+// service.
+func statusError(code int, u string) error {
+	message := fmt.Sprintf("unexpected status %d from %s", code, u)
+	switch {
+	case code == http.StatusGatewayTimeout:
+		return serviceerror.New(serviceerror.Timeout, message)
+	case code == http.StatusTooManyRequests || code >= 500:
+		return serviceerror.New(serviceerror.Unavailable, message)
+	}
+	return serviceerror.New(serviceerror.MalformedPayload, message)
+}
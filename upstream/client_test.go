@@ -0,0 +1,343 @@
+package upstream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/serviceerror"
+)
+
+type reading struct {
+	Temperature float64   `json:"temp"`
+	Date        time.Time `json:"date"`
+}
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:          3,
+	BaseBackoff:          time.Millisecond,
+	MaxBackoff:           5 * time.Millisecond,
+	Jitter:               0.5,
+	RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+}
+
+func TestGetDecodesReading(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("at")
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	var r reading
+	found, err := New(server.URL+"/", WithRetryPolicy(testRetryPolicy)).Get(context.Background(), time.Date(2018, 8, 12, 12, 0, 0, 0, time.FixedZone("", 3600)), &r)
+	assert.Nil(t, err)
+	assert.True(t, found)
+
+	assert.Equal(t, "2018-08-12T12:00:00+0100", query)
+	assert.Equal(t, reading{Temperature: 10.5, Date: time.Date(2018, 8, 12, 0, 0, 0, 0, time.UTC)}, r)
+}
+
+func TestGetReturnsNotFoundOn404(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	var r reading
+	found, err := New(server.URL, WithRetryPolicy(testRetryPolicy)).Get(context.Background(), time.Now(), &r)
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestGetRetriesRetryableStatus(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	var r reading
+	found, err := New(server.URL, WithRetryPolicy(testRetryPolicy)).Get(context.Background(), time.Now(), &r)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestGetGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var r reading
+	_, err := New(server.URL, WithRetryPolicy(testRetryPolicy)).Get(context.Background(), time.Now(), &r)
+	assert.Equal(t, serviceerror.Unavailable, serviceerror.KindOf(err))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestGetReturnsMalformedPayloadErrorOnInvalidJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"temp":`))
+	}))
+	defer server.Close()
+
+	var r reading
+	_, err := New(server.URL, WithRetryPolicy(testRetryPolicy)).Get(context.Background(), time.Now(), &r)
+	assert.Equal(t, serviceerror.MalformedPayload, serviceerror.KindOf(err))
+}
+
+func TestGetDoesNotRetryNonRetryableStatus(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	var r reading
+	_, err := New(server.URL, WithRetryPolicy(testRetryPolicy)).Get(context.Background(), time.Now(), &r)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestGetDoesNotRetryPastContextDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	policy := testRetryPolicy
+	policy.BaseBackoff = time.Second
+	policy.MaxBackoff = time.Second
+	policy.Jitter = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var r reading
+	_, err := New(server.URL, WithRetryPolicy(policy)).Get(ctx, time.Now(), &r)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestGetStopsRetryingSharedRequestAtCallerDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	policy := testRetryPolicy
+	policy.MaxAttempts = 10
+	policy.BaseBackoff = 40 * time.Millisecond
+	policy.MaxBackoff = 40 * time.Millisecond
+	policy.Jitter = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	var r reading
+	_, err := New(server.URL, WithRetryPolicy(policy)).Get(ctx, time.Now(), &r)
+
+	// The retry which would outlast the deadline is never made, so the last
+	// upstream error is returned rather than the caller timing out.
+	assert.Equal(t, serviceerror.Unavailable, serviceerror.KindOf(err))
+	assert.True(t, time.Since(start) < 100*time.Millisecond)
+}
+
+func TestGetExtendsSharedRequestToLatestCallerDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	policy := testRetryPolicy
+	policy.MaxAttempts = 2
+	policy.BaseBackoff = 100 * time.Millisecond
+	policy.MaxBackoff = 100 * time.Millisecond
+	policy.Jitter = 0
+
+	client := New(server.URL, WithRetryPolicy(policy))
+	at := time.Date(2018, 8, 12, 0, 0, 0, 0, time.UTC)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	short := make(chan error)
+	go func() {
+		var r reading
+		_, err := client.Get(ctx, at, &r)
+		short <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	var r reading
+	found, err := client.Get(context.Background(), at, &r)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.NotNil(t, <-short)
+}
+
+func TestBackoffGrowsExponentiallyUpToMax(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 400*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, time.Second, policy.backoff(5))
+	assert.Equal(t, time.Second, policy.backoff(50))
+}
+
+func TestBackoffWithoutMaxDoesNotOverflow(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond}
+
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, time.Hour, policy.backoff(100))
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestGetSendsUserAgentAndHeaders(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	var r reading
+	_, err := New(server.URL, WithUserAgent("charlyedu-test"), WithHeader("X-Api-Key", "secret")).Get(context.Background(), time.Now(), &r)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "charlyedu-test", header.Get("User-Agent"))
+	assert.Equal(t, "secret", header.Get("X-Api-Key"))
+}
+
+func TestGetUsesTransportWithoutModifyingHTTPClient(t *testing.T) {
+	httpClient := &http.Client{}
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.WriteString(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`)
+		return rec.Result(), nil
+	})
+
+	var r reading
+	found, err := New("http://temperature", WithHTTPClient(httpClient), WithTransport(transport)).Get(context.Background(), time.Now(), &r)
+	assert.Nil(t, err)
+	assert.True(t, found)
+
+	assert.Equal(t, 10.5, r.Temperature)
+	assert.Nil(t, httpClient.Transport)
+}
+
+func TestGetRetriesAttemptsExceedingTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	var r reading
+	found, err := New(server.URL, WithTimeout(20*time.Millisecond), WithRetryPolicy(testRetryPolicy)).Get(context.Background(), time.Now(), &r)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestGetReturnsTimeoutErrorOnSlowResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	var r reading
+	_, err := New(server.URL, WithTimeout(20*time.Millisecond)).Get(context.Background(), time.Now(), &r)
+	assert.Equal(t, serviceerror.Timeout, serviceerror.KindOf(err))
+}
+
+func TestGetSharesConcurrentRequestsForSameDay(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL)
+	results := make(chan reading)
+	for i := 0; i < 5; i++ {
+		go func(i int) {
+			var r reading
+			_, err := client.Get(context.Background(), time.Date(2018, 8, 12, i, 0, 0, 0, time.UTC), &r)
+			assert.Nil(t, err)
+			results <- r
+		}(i)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, 10.5, (<-results).Temperature)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestGetKeepsSharedRequestGoingWhileAnyCallerWaits(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL)
+	at := time.Date(2018, 8, 12, 0, 0, 0, 0, time.UTC)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	abandoned := make(chan error)
+	go func() {
+		var r reading
+		_, err := client.Get(ctx, at, &r)
+		abandoned <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	completed := make(chan error)
+	go func() {
+		var r reading
+		_, err := client.Get(context.Background(), at, &r)
+		completed <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-abandoned)
+
+	close(release)
+	assert.Nil(t, <-completed)
+}
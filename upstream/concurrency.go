@@ -0,0 +1,134 @@
+package upstream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/svranesevic/charlyedu/serviceerror"
+)
+
+// ConcurrencySettings configures a ConcurrencyLimiter. Requests which take
+// longer than Latency, time out or find the backing service unavailable
+// count as overload.
+type ConcurrencySettings struct {
+	Initial int
+	Min     int
+	Max     int
+	Latency time.Duration
+	// Backoff is the factor the limit is multiplied by on overload.
+	Backoff float64
+}
+
+// ConcurrencyLimiter bounds the number of requests in flight to a backing
+// service. The bound grows by one for every limit's worth of healthy
+// requests and shrinks by the Backoff factor on overload. It is safe for
+// concurrent use.
+type ConcurrencyLimiter struct {
+	settings ConcurrencySettings
+
+	mu           sync.Mutex
+	limit        float64
+	inFlight     int
+	lastDecrease time.Time
+	released     chan struct{}
+}
+
+// ConcurrencyStats is a snapshot of a ConcurrencyLimiter's state.
+type ConcurrencyStats struct {
+	Limit    int `json:"limit"`
+	InFlight int `json:"in_flight"`
+}
+
+func NewConcurrencyLimiter(settings ConcurrencySettings) *ConcurrencyLimiter {
+	if settings.Min < 1 {
+		settings.Min = 1
+	}
+	if settings.Max < settings.Min {
+		settings.Max = settings.Min
+	}
+	if settings.Initial < settings.Min {
+		settings.Initial = settings.Min
+	}
+	if settings.Initial > settings.Max {
+		settings.Initial = settings.Max
+	}
+	if settings.Backoff <= 0 || settings.Backoff >= 1 {
+		settings.Backoff = 0.9
+	}
+
+	return &ConcurrencyLimiter{
+		settings: settings,
+		limit:    float64(settings.Initial),
+		released: make(chan struct{}),
+	}
+}
+
+func (l *ConcurrencyLimiter) Stats() ConcurrencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return ConcurrencyStats{Limit: int(l.limit), InFlight: l.inFlight}
+}
+
+// acquire blocks until a request may be made, returning the time it was
+// allowed at.
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) (time.Time, error) {
+	for {
+		l.mu.Lock()
+		if l.inFlight < int(l.limit) {
+			l.inFlight++
+			l.mu.Unlock()
+			return time.Now(), nil
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return time.Time{}, requestError(ctx, ctx.Err())
+		}
+	}
+}
+
+// release records the outcome of a request allowed at start and adjusts the
+// limit accordingly. Requests abandoned by their caller say nothing about
+// the backing service and leave the limit as it is.
+func (l *ConcurrencyLimiter) release(ctx context.Context, start time.Time, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case ctx.Err() != nil:
+	case l.overloaded(start, err):
+		// Requests already in flight when the limit was last decreased
+		// report the same overload, so they don't decrease it again.
+		if start.After(l.lastDecrease) {
+			l.limit *= l.settings.Backoff
+			if l.limit < float64(l.settings.Min) {
+				l.limit = float64(l.settings.Min)
+			}
+			l.lastDecrease = time.Now()
+		}
+	case float64(l.inFlight*2) >= l.limit:
+		// Only grow while the limit is being used, so that it does not
+		// drift towards Max while the backing service is barely loaded.
+		l.limit += 1 / l.limit
+		if l.limit > float64(l.settings.Max) {
+			l.limit = float64(l.settings.Max)
+		}
+	}
+
+	l.inFlight--
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+func (l *ConcurrencyLimiter) overloaded(start time.Time, err error) bool {
+	if l.settings.Latency > 0 && time.Since(start) > l.settings.Latency {
+		return true
+	}
+	kind := serviceerror.KindOf(err)
+	return err != nil && (kind == serviceerror.Unavailable || kind == serviceerror.Timeout)
+}
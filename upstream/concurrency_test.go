@@ -0,0 +1,125 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/serviceerror"
+)
+
+func TestConcurrencyLimiterGrowsWhileHealthy(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencySettings{Initial: 2, Min: 1, Max: 3, Latency: time.Second})
+
+	for i := 0; i < 10; i++ {
+		first, _ := l.acquire(context.Background())
+		second, _ := l.acquire(context.Background())
+		l.release(context.Background(), first, nil)
+		l.release(context.Background(), second, nil)
+	}
+	assert.Equal(t, ConcurrencyStats{Limit: 3, InFlight: 0}, l.Stats())
+}
+
+func TestConcurrencyLimiterDoesNotGrowWhileUnderused(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencySettings{Initial: 4, Min: 1, Max: 8})
+
+	for i := 0; i < 20; i++ {
+		start, _ := l.acquire(context.Background())
+		l.release(context.Background(), start, nil)
+	}
+	assert.Equal(t, 4, l.Stats().Limit)
+}
+
+func TestConcurrencyLimiterBacksOffOnOverload(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencySettings{Initial: 10, Min: 2, Max: 10, Backoff: 0.5})
+
+	start, _ := l.acquire(context.Background())
+	l.release(context.Background(), start, serviceerror.New(serviceerror.Unavailable, "unavailable"))
+	assert.Equal(t, 5, l.Stats().Limit)
+
+	start, _ = l.acquire(context.Background())
+	l.release(context.Background(), start, serviceerror.New(serviceerror.Timeout, "timeout"))
+	assert.Equal(t, 2, l.Stats().Limit)
+
+	start, _ = l.acquire(context.Background())
+	l.release(context.Background(), start, serviceerror.New(serviceerror.Timeout, "timeout"))
+	assert.Equal(t, 2, l.Stats().Limit)
+}
+
+func TestConcurrencyLimiterBacksOffOnceForConcurrentOverload(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencySettings{Initial: 10, Min: 1, Max: 10, Backoff: 0.5})
+
+	first, _ := l.acquire(context.Background())
+	second, _ := l.acquire(context.Background())
+	l.release(context.Background(), first, serviceerror.New(serviceerror.Unavailable, "unavailable"))
+	l.release(context.Background(), second, serviceerror.New(serviceerror.Unavailable, "unavailable"))
+	assert.Equal(t, 5, l.Stats().Limit)
+}
+
+func TestConcurrencyLimiterIgnoresAbandonedRequests(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencySettings{Initial: 4, Min: 1, Max: 4})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	start, _ := l.acquire(ctx)
+	cancel()
+	l.release(ctx, start, errors.New("cancelled"))
+	assert.Equal(t, ConcurrencyStats{Limit: 4, InFlight: 0}, l.Stats())
+}
+
+func TestConcurrencyLimiterWaitsForRelease(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencySettings{Initial: 1, Min: 1, Max: 1})
+
+	start, _ := l.acquire(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := l.acquire(ctx)
+	assert.Equal(t, serviceerror.Timeout, serviceerror.KindOf(err))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		l.release(context.Background(), start, nil)
+	}()
+	_, err = l.acquire(context.Background())
+	assert.Nil(t, err)
+}
+
+func TestGetLimitsRequestsInFlight(t *testing.T) {
+	var inFlight, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithConcurrencyLimiter(NewConcurrencyLimiter(ConcurrencySettings{Initial: 2, Min: 2, Max: 2})))
+
+	var wg sync.WaitGroup
+	for day := 1; day <= 10; day++ {
+		wg.Add(1)
+		go func(day int) {
+			defer wg.Done()
+
+			var r reading
+			_, err := c.Get(context.Background(), time.Date(2018, 8, day, 0, 0, 0, 0, time.UTC), &r)
+			assert.Nil(t, err)
+		}(day)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+}
@@ -0,0 +1,162 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HedgePolicy describes when a second, identical request is sent while the
+// first one is still outstanding. The hedge is sent once the first request
+// has been outstanding for the Percentile, from 0 to 100, of recently observed
+// latencies, or for Delay while too few latencies have been observed or
+// Percentile is zero. At most MaxRate of requests are hedged.
+type HedgePolicy struct {
+	Delay      time.Duration
+	Percentile float64
+	MaxRate    float64
+}
+
+// Validate checks that the policy's percentile and rate are in range.
+func (p HedgePolicy) Validate() error {
+	if p.Percentile < 0 || p.Percentile > 100 || math.IsNaN(p.Percentile) {
+		return fmt.Errorf("hedge percentile %v must be between 0 and 100", p.Percentile)
+	}
+	if p.MaxRate < 0 || p.MaxRate > 1 || math.IsNaN(p.MaxRate) {
+		return fmt.Errorf("hedge max rate %v must be between 0 and 1", p.MaxRate)
+	}
+	return nil
+}
+
+const (
+	hedgeLatencySamples    = 256
+	hedgeMinLatencySamples = 20
+	// hedgeMaxBudget bounds how many hedges unused budget can accumulate to,
+	// so that a quiet period does not allow a burst of hedges afterwards.
+	hedgeMaxBudget = 10
+)
+
+type hedger struct {
+	policy HedgePolicy
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	budget    float64
+}
+
+func newHedger(policy HedgePolicy) *hedger {
+	return &hedger{policy: policy, latencies: make([]time.Duration, 0, hedgeLatencySamples)}
+}
+
+// delay returns how long to wait for a response before hedging, or zero if
+// no hedge should be sent.
+func (h *hedger) delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.policy.Percentile <= 0 || len(h.latencies) < hedgeMinLatencySamples {
+		return h.policy.Delay
+	}
+
+	sorted := make([]time.Duration, len(h.latencies))
+	copy(sorted, h.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	i := int(h.policy.Percentile / 100 * float64(len(sorted)-1))
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+func (h *hedger) observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.latencies) < hedgeLatencySamples {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+	h.latencies[h.next] = latency
+	h.next = (h.next + 1) % hedgeLatencySamples
+}
+
+// credit grows the hedge budget for a request being made.
+func (h *hedger) credit() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.budget += h.policy.MaxRate; h.budget > hedgeMaxBudget {
+		h.budget = hedgeMaxBudget
+	}
+}
+
+// allow reports whether the budget allows a hedge, taking it if it does.
+func (h *hedger) allow() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.budget < 1 {
+		return false
+	}
+	h.budget--
+	return true
+}
+
+type attemptResult struct {
+	body      []byte
+	found     bool
+	retryable bool
+	err       error
+}
+
+// hedgedGet makes a request through get, hedging it according to the
+// client's HedgePolicy. The first successful response wins and the other
+// request is cancelled.
+func (c *Client) hedgedGet(ctx context.Context, u string) ([]byte, bool, bool, error) {
+	h := c.hedger
+	h.credit()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan attemptResult, 2)
+	send := func() {
+		start := time.Now()
+		body, found, retryable, err := c.get(ctx, u)
+		if err == nil {
+			h.observe(time.Since(start))
+		}
+		results <- attemptResult{body: body, found: found, retryable: retryable, err: err}
+	}
+
+	go send()
+	outstanding := 1
+
+	if d := h.delay(); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case r := <-results:
+			return r.body, r.found, r.retryable, r.err
+		case <-timer.C:
+			if h.allow() {
+				go send()
+				outstanding++
+			}
+		}
+	}
+
+	var r attemptResult
+	for ; outstanding > 0; outstanding-- {
+		if r = <-results; r.err == nil {
+			break
+		}
+	}
+	return r.body, r.found, r.retryable, r.err
+}
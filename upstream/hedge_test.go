@@ -0,0 +1,106 @@
+package upstream
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHedgesSlowRequest(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithHedging(HedgePolicy{Delay: 10 * time.Millisecond, MaxRate: 1}))
+
+	start := time.Now()
+	var r reading
+	found, err := c.Get(context.Background(), time.Date(2018, 8, 12, 0, 0, 0, 0, time.UTC), &r)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 10.5, r.Temperature)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+}
+
+func TestGetDoesNotHedgeOverMaxRate(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithHedging(HedgePolicy{Delay: time.Millisecond, MaxRate: 0.5}))
+
+	for day := 1; day <= 4; day++ {
+		var r reading
+		_, err := c.Get(context.Background(), time.Date(2018, 8, day, 0, 0, 0, 0, time.UTC), &r)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+}
+
+func TestGetWaitsForHedgeWhenFirstRequestFails(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(20 * time.Millisecond)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		time.Sleep(40 * time.Millisecond)
+		w.Write([]byte(`{"temp":10.5,"date":"2018-08-12T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithHedging(HedgePolicy{Delay: 5 * time.Millisecond, MaxRate: 1}))
+
+	var r reading
+	found, err := c.Get(context.Background(), time.Date(2018, 8, 12, 0, 0, 0, 0, time.UTC), &r)
+	assert.Nil(t, err)
+	assert.True(t, found)
+}
+
+func TestHedgeDelayUsesPercentileOfObservedLatencies(t *testing.T) {
+	h := newHedger(HedgePolicy{Delay: time.Second, Percentile: 90})
+	assert.Equal(t, time.Second, h.delay())
+
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 90*time.Millisecond, h.delay())
+}
+
+func TestHedgePolicyRejectsPercentileOutOfRange(t *testing.T) {
+	assert.Nil(t, HedgePolicy{Percentile: 95}.Validate())
+	assert.NotNil(t, HedgePolicy{Percentile: 101}.Validate())
+	assert.NotNil(t, HedgePolicy{Percentile: -1}.Validate())
+	assert.NotNil(t, HedgePolicy{Percentile: math.NaN()}.Validate())
+
+	assert.Nil(t, New("http://localhost", WithHedging(HedgePolicy{Percentile: 950})).hedger)
+}
+
+func TestHedgeDelayAtHundredthPercentileIsSlowestLatency(t *testing.T) {
+	h := newHedger(HedgePolicy{Delay: time.Second, Percentile: 100})
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 100*time.Millisecond, h.delay())
+}
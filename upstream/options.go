@@ -0,0 +1,78 @@
+package upstream
+
+import (
+	"net/http"
+	"time"
+)
+
+type Option func(*Client)
+
+// WithHTTPClient makes the client issue its requests through httpClient
+// instead of http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTransport replaces the transport of the underlying http.Client. The
+// http.Client itself is copied, so a shared one is never modified.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithHeader adds a header sent with every request.
+func WithHeader(key string, value string) Option {
+	return func(c *Client) {
+		c.headers.Add(key, value)
+	}
+}
+
+// WithTimeout bounds each attempt at a request, on top of any deadline of
+// the request context.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+func WithRetryPolicy(retry RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = retry
+	}
+}
+
+// WithRateLimit limits the requests made through the client to rate per
+// second on average, with bursts of up to burst requests. Retries count
+// towards the limit too.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *Client) {
+		if rate > 0 {
+			c.limiter = newTokenBucket(rate, burst)
+		}
+	}
+}
+
+// WithHedging sends a second request when the first one is slow to respond,
+// according to policy. Hedging stays off if the policy is not valid.
+func WithHedging(policy HedgePolicy) Option {
+	return func(c *Client) {
+		if policy.Validate() == nil && (policy.Delay > 0 || policy.Percentile > 0) {
+			c.hedger = newHedger(policy)
+		}
+	}
+}
+
+// WithConcurrencyLimiter bounds the number of requests in flight with limiter.
+// A limiter may be shared by clients of the same backing service.
+func WithConcurrencyLimiter(limiter *ConcurrencyLimiter) Option {
+	return func(c *Client) {
+		c.concurrency = limiter
+	}
+}
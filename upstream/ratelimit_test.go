@@ -0,0 +1,51 @@
+package upstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svranesevic/charlyedu/serviceerror"
+)
+
+func TestTokenBucketAllowsBurstThenLimitsRate(t *testing.T) {
+	b := newTokenBucket(100, 5)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, b.wait(context.Background()))
+	}
+	assert.True(t, time.Since(start) < 10*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, b.wait(context.Background()))
+	}
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+}
+
+func TestTokenBucketFailsFastWhenDeadlineWouldBeExceeded(t *testing.T) {
+	b := newTokenBucket(1, 1)
+	assert.Nil(t, b.wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := b.wait(ctx)
+	assert.Equal(t, serviceerror.Timeout, serviceerror.KindOf(err))
+	assert.True(t, time.Since(start) < 10*time.Millisecond)
+}
+
+func TestTokenBucketReturnsTokenOnCancelledWait(t *testing.T) {
+	b := newTokenBucket(10, 1)
+	assert.Nil(t, b.wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotNil(t, b.wait(ctx))
+
+	start := time.Now()
+	assert.Nil(t, b.wait(context.Background()))
+	assert.True(t, time.Since(start) < 150*time.Millisecond)
+}
@@ -0,0 +1,75 @@
+package upstream
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy describes how failed upstream GETs are retried. Attempts are
+// spaced by an exponential backoff starting at BaseBackoff and capped at
+// MaxBackoff, or an hour without one, of which up to a Jitter fraction is
+// randomised away.
+type RetryPolicy struct {
+	MaxAttempts          int
+	BaseBackoff          time.Duration
+	MaxBackoff           time.Duration
+	Jitter               float64
+	RetryableStatusCodes []int
+}
+
+// maxBackoff caps the backoff of policies without a MaxBackoff, well before
+// doubling it could overflow.
+const maxBackoff = time.Hour
+
+// NoRetry makes a single attempt per request.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+func (p RetryPolicy) isRetryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry, counting from 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = maxBackoff
+	}
+
+	d := p.BaseBackoff
+	for i := 1; i < retry && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// wait sleeps for the backoff before the given retry. It returns false,
+// without sleeping, when ctx would expire before the retry could be made.
+func (p RetryPolicy) wait(ctx context.Context, retry int) bool {
+	d := p.backoff(retry)
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(d).After(deadline) {
+		return false
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,163 @@
+package upstream
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// flightGroup lets concurrent requests for the same key share a single
+// in-flight call. The call runs detached from the cancellation of any single
+// caller, and is only cancelled once every caller waiting for it gave up. It
+// does carry the latest deadline of its callers, so it stops retrying and
+// waiting once none of them could use its result anymore.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	ctx     *flightContext
+	waiters int
+
+	body  []byte
+	found bool
+	err   error
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: make(map[string]*flight)}
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, bool, error)) ([]byte, bool, error) {
+	g.mu.Lock()
+	f, ok := g.calls[key]
+	if !ok {
+		f = &flight{done: make(chan struct{}), ctx: newFlightContext(ctx)}
+		g.calls[key] = f
+
+		go func() {
+			f.body, f.found, f.err = fn(f.ctx)
+			g.forget(key, f)
+			f.ctx.cancel()
+			close(f.done)
+		}()
+	} else {
+		f.ctx.extend(ctx)
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.body, f.found, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		if f.waiters--; f.waiters == 0 {
+			f.ctx.cancel()
+			g.forgetLocked(key, f)
+		}
+		g.mu.Unlock()
+
+		return nil, false, requestError(ctx, ctx.Err())
+	}
+}
+
+func (g *flightGroup) forget(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.forgetLocked(key, f)
+}
+
+func (g *flightGroup) forgetLocked(key string, f *flight) {
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+}
+
+// flightContext is the context a shared call runs with. It is not cancelled
+// with any of its callers, but expires at the latest of their deadlines, if
+// all of them have one.
+type flightContext struct {
+	context.Context
+	cancelFunc context.CancelFunc
+
+	mu       sync.Mutex
+	deadline time.Time
+	timer    *time.Timer
+	expired  bool
+}
+
+func newFlightContext(leader context.Context) *flightContext {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &flightContext{Context: ctx, cancelFunc: cancel}
+
+	if deadline, ok := leader.Deadline(); ok {
+		c.deadline = deadline
+		c.timer = time.AfterFunc(time.Until(deadline), c.expire)
+	}
+	return c
+}
+
+// extend moves the deadline to that of ctx if it is later, or drops it if ctx
+// has none.
+func (c *flightContext) extend(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.expired || c.timer == nil {
+		return
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		c.timer.Stop()
+		c.timer, c.deadline = nil, time.Time{}
+		return
+	}
+	if deadline.After(c.deadline) && c.timer.Stop() {
+		c.deadline = deadline
+		c.timer = time.AfterFunc(time.Until(deadline), c.expire)
+	}
+}
+
+func (c *flightContext) expire() {
+	c.mu.Lock()
+	c.expired = true
+	c.mu.Unlock()
+
+	c.cancelFunc()
+}
+
+func (c *flightContext) cancel() {
+	c.mu.Lock()
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.mu.Unlock()
+
+	c.cancelFunc()
+}
+
+func (c *flightContext) Deadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.deadline, c.timer != nil
+}
+
+func (c *flightContext) Err() error {
+	if err := c.Context.Err(); err == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.expired {
+		return context.DeadlineExceeded
+	}
+	return context.Canceled
+}
@@ -0,0 +1,153 @@
+package warmer
+
+import (
+	"context"
+	"time"
+
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	log "go.uber.org/zap"
+)
+
+// FetchFunc obtains, and thereby caches, the reading for a single day.
+type FetchFunc func(ctx context.Context, at time.Time) error
+
+// Checkpoint persists the last day a Warmer fetched, so that a backfill can
+// resume where it left off.
+type Checkpoint interface {
+	Load() (time.Time, bool, error)
+	Save(at time.Time) error
+}
+
+// Warmer fetches every day from rangefetcher.FirstDay up to today, one per interval, so
+// that requests for them can be served from cache.
+type Warmer struct {
+	name       string
+	fetch      FetchFunc
+	interval   time.Duration
+	retryDelay time.Duration
+	checkpoint Checkpoint
+
+	// from is the day after the last one before today which was fetched.
+	from time.Time
+	now  func() time.Time
+}
+
+// New returns a Warmer fetching a day every interval. Without a checkpoint
+// progress is only kept in memory, so a backfill resumes where the last one
+// left off but starts over from rangefetcher.FirstDay after a restart.
+func New(name string, fetch FetchFunc, interval time.Duration, checkpoint Checkpoint) *Warmer {
+	return &Warmer{
+		name:       name,
+		fetch:      fetch,
+		interval:   interval,
+		retryDelay: 30 * time.Second,
+		checkpoint: checkpoint,
+		from:       rangefetcher.FirstDay,
+		now:        time.Now,
+	}
+}
+
+// Run backfills every day up to today and then fetches each new day as the
+// date rolls over, until ctx is done.
+func (w *Warmer) Run(ctx context.Context) error {
+	for {
+		if err := w.Backfill(ctx); err != nil {
+			return err
+		}
+
+		now := w.now().UTC()
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		if !w.sleep(ctx, tomorrow.Sub(now)) {
+			return ctx.Err()
+		}
+	}
+}
+
+// Backfill fetches every day after the checkpoint, or after the last day
+// fetched before if there is none, up to and including today, checkpointing
+// every day up to yesterday. Days which fail are retried until they succeed or ctx is done.
+func (w *Warmer) Backfill(ctx context.Context) error {
+	next, err := w.resumeFrom()
+	if err != nil {
+		return err
+	}
+
+	today := day(w.now())
+	if next.After(today) {
+		return nil
+	}
+
+	total := int(today.Sub(next).Hours()/24) + 1
+	log.S().Infof("warmer %s: backfilling %d days from %s", w.name, total, next.Format("2006-01-02"))
+
+	for done := 0; !next.After(today); {
+		if err := w.fetch(ctx, next); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			log.S().Errorf("warmer %s: failed to fetch %s, retrying in %s, %+v", w.name, next.Format("2006-01-02"), w.retryDelay, err)
+			if !w.sleep(ctx, w.retryDelay) {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		// Today's reading can still change and is not persisted, so it is
+		// warmed again by the next backfill rather than checkpointed.
+		if next.Before(today) {
+			w.from = next.AddDate(0, 0, 1)
+			if w.checkpoint != nil {
+				if err := w.checkpoint.Save(next); err != nil {
+					log.S().Errorf("warmer %s: failed to save checkpoint, %+v", w.name, err)
+				}
+			}
+		}
+
+		if done++; done%1000 == 0 || done == total {
+			log.S().Infof("warmer %s: fetched up to %s (%d/%d days)", w.name, next.Format("2006-01-02"), done, total)
+		}
+		next = next.AddDate(0, 0, 1)
+
+		if !w.sleep(ctx, w.interval) {
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (w *Warmer) resumeFrom() (time.Time, error) {
+	if w.checkpoint == nil {
+		return w.from, nil
+	}
+
+	last, ok, err := w.checkpoint.Load()
+	if err != nil || !ok {
+		return w.from, err
+	}
+	return day(last).AddDate(0, 0, 1), nil
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func (w *Warmer) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// day returns midnight of the UTC day of at.
+func day(at time.Time) time.Time {
+	at = at.UTC()
+	return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,147 @@
+package warmer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryCheckpoint struct {
+	at  time.Time
+	set bool
+}
+
+func (c *memoryCheckpoint) Load() (time.Time, bool, error) {
+	return c.at, c.set, nil
+}
+
+func (c *memoryCheckpoint) Save(at time.Time) error {
+	c.at, c.set = at, true
+	return nil
+}
+
+func newTestWarmer(fetch FetchFunc, checkpoint Checkpoint) *Warmer {
+	w := New("test", fetch, 0, checkpoint)
+	w.from = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time {
+		return time.Date(2019, 1, 4, 15, 30, 0, 0, time.UTC)
+	}
+	w.retryDelay = time.Millisecond
+	return w
+}
+
+func TestBackfillFetchesEveryDayUpToToday(t *testing.T) {
+	fetched := make([]time.Time, 0)
+	checkpoint := &memoryCheckpoint{}
+	w := newTestWarmer(func(ctx context.Context, at time.Time) error {
+		fetched = append(fetched, at)
+		return nil
+	}, checkpoint)
+
+	assert.Nil(t, w.Backfill(context.Background()))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC),
+	}, fetched)
+	assert.Equal(t, time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC), checkpoint.at)
+}
+
+func TestBackfillWarmsTodayAgainAfterRestart(t *testing.T) {
+	checkpoint := &memoryCheckpoint{}
+	fetch := func(ctx context.Context, at time.Time) error {
+		return nil
+	}
+	assert.Nil(t, newTestWarmer(fetch, checkpoint).Backfill(context.Background()))
+
+	fetched := make([]time.Time, 0)
+	w := newTestWarmer(func(ctx context.Context, at time.Time) error {
+		fetched = append(fetched, at)
+		return nil
+	}, checkpoint)
+	assert.Nil(t, w.Backfill(context.Background()))
+
+	assert.Equal(t, []time.Time{time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC)}, fetched)
+}
+
+func TestBackfillResumesAfterCheckpoint(t *testing.T) {
+	fetched := make([]time.Time, 0)
+	checkpoint := &memoryCheckpoint{at: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), set: true}
+	w := newTestWarmer(func(ctx context.Context, at time.Time) error {
+		fetched = append(fetched, at)
+		return nil
+	}, checkpoint)
+
+	assert.Nil(t, w.Backfill(context.Background()))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC),
+	}, fetched)
+}
+
+func TestBackfillRetriesFailedDays(t *testing.T) {
+	attempts := make(map[time.Time]int)
+	w := newTestWarmer(func(ctx context.Context, at time.Time) error {
+		if attempts[at]++; attempts[at] < 3 {
+			return errors.New("upstream error")
+		}
+		return nil
+	}, nil)
+
+	assert.Nil(t, w.Backfill(context.Background()))
+
+	assert.Len(t, attempts, 4)
+	for _, n := range attempts {
+		assert.Equal(t, 3, n)
+	}
+}
+
+func TestBackfillStopsOnCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	w := newTestWarmer(func(ctx context.Context, at time.Time) error {
+		calls++
+		cancel()
+		return nil
+	}, nil)
+
+	assert.Equal(t, context.Canceled, w.Backfill(ctx))
+	assert.Equal(t, 1, calls)
+}
+
+func TestRunFetchesOnlyNewDaysAfterDateRollsOverWithoutCheckpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := time.Date(2019, 1, 4, 23, 59, 59, 990000000, time.UTC)
+
+	fetched := make([]time.Time, 0)
+	w := newTestWarmer(func(ctx context.Context, at time.Time) error {
+		fetched = append(fetched, at)
+		switch at {
+		case time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC):
+			clock = time.Date(2019, 1, 5, 23, 59, 59, 990000000, time.UTC)
+		case time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC):
+			cancel()
+		}
+		return nil
+	}, nil)
+	w.now = func() time.Time {
+		return clock
+	}
+
+	assert.Equal(t, context.Canceled, w.Run(ctx))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC),
+	}, fetched)
+}
@@ -0,0 +1,45 @@
+package weatherservice
+
+import "math"
+
+// Comfort holds the comfort metrics derived from the temperature and the
+// wind, as documented by ComfortFormulae.
+type Comfort struct {
+	// WindChill is nil where the wind chill index is not defined.
+	WindChill           *float64 `json:"wind_chill"`
+	ApparentTemperature float64  `json:"apparent_temperature"`
+}
+
+// Formula documents how a metric is computed and where it is valid.
+type Formula struct {
+	Formula  string `json:"formula"`
+	Validity string `json:"validity"`
+}
+
+// ComfortFormulae documents every field of Comfort, keyed by its JSON name.
+var ComfortFormulae = map[string]Formula{
+	"wind_chill": {
+		Formula:  "13.12 + 0.6215*T - 11.37*V^0.16 + 0.3965*T*V^0.16, T in degrees Celsius and V in km/h at 10m (JAG/TI wind chill index)",
+		Validity: "T <= 10 degrees Celsius and V > 4.8 km/h, null otherwise",
+	},
+	"apparent_temperature": {
+		Formula:  "the wind chill index where it is valid, T otherwise; humidity is not available, so heat is not accounted for",
+		Validity: "all temperatures and wind speeds",
+	},
+}
+
+// ComputeComfort derives the comfort metrics for a temperature in degrees
+// Celsius and a wind speed in meters per second.
+func ComputeComfort(temperature float64, windSpeed float64) Comfort {
+	c := Comfort{ApparentTemperature: temperature}
+
+	kmh := windSpeed * 3.6
+	if temperature <= 10 && kmh > 4.8 {
+		v := math.Pow(kmh, 0.16)
+		windChill := 13.12 + 0.6215*temperature - 11.37*v + 0.3965*temperature*v
+		c.WindChill = &windChill
+		c.ApparentTemperature = windChill
+	}
+
+	return c
+}
@@ -0,0 +1,26 @@
+package weatherservice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeComfortWindChill(t *testing.T) {
+	// -10 degrees Celsius at 20 km/h has a wind chill of about -17.9.
+	c := ComputeComfort(-10, 20/3.6)
+
+	assert.NotNil(t, c.WindChill)
+	assert.InDelta(t, -17.9, *c.WindChill, 0.05)
+	assert.Equal(t, *c.WindChill, c.ApparentTemperature)
+}
+
+func TestComputeComfortOutsideWindChillValidity(t *testing.T) {
+	warm := ComputeComfort(20, 10)
+	assert.Nil(t, warm.WindChill)
+	assert.Equal(t, 20.0, warm.ApparentTemperature)
+
+	calm := ComputeComfort(-5, 1)
+	assert.Nil(t, calm.WindChill)
+	assert.Equal(t, -5.0, calm.ApparentTemperature)
+}
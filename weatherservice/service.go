@@ -2,63 +2,47 @@ package weatherservice
 
 import (
 	"context"
-	"errors"
-	"math"
-	"sort"
 	"sync"
 	"time"
 
+	"github.com/svranesevic/charlyedu/rangefetcher"
 	"github.com/svranesevic/charlyedu/temperatureservice"
 	"github.com/svranesevic/charlyedu/windspeedservice"
-	log "go.uber.org/zap"
 )
 
 type Service interface {
-	GetForRange(ctx context.Context, from time.Time, to time.Time) ([]Weather, error)
+	GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]Weather, error)
 	GetForDateTime(ctx context.Context, at time.Time) (*Weather, error)
 }
 
 type weatherService struct {
-	ts  temperatureservice.Service
-	wss windspeedservice.Service
+	ts      temperatureservice.Service
+	wss     windspeedservice.Service
+	fetcher rangefetcher.Fetcher
 }
 
-func New(ts temperatureservice.Service, wss windspeedservice.Service) Service {
-	return weatherService{ts: ts, wss: wss}
+func New(ts temperatureservice.Service, wss windspeedservice.Service, fetcher rangefetcher.Fetcher) Service {
+	return weatherService{ts: ts, wss: wss, fetcher: fetcher}
 }
 
-func (ws weatherService) GetForRange(ctx context.Context, from time.Time, to time.Time) ([]Weather, error) {
-	if from.After(to) {
-		return []Weather{}, errors.New("`start` must be before `end`")
+func (ws weatherService) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]Weather, error) {
+	results, err := ws.fetcher.FetchDays(ctx, from, to, func(ctx context.Context, at time.Time) (rangefetcher.Dated, error) {
+		r, err := ws.GetForDateTime(ctx, at)
+		if r == nil {
+			return nil, err
+		}
+		return *r, err
+	}, opts...)
+	if err != nil {
+		return []Weather{}, err
 	}
-	to = to.Add(24 * time.Hour)
 
-	numDays := int(math.Ceil(to.Sub(from).Hours() / 24))
-	tempChan := make(chan Weather, numDays)
-	var wg sync.WaitGroup
-
-	for at := from; at.Before(to); at = at.Add(24 * time.Hour) {
-		wg.Add(1)
-		go func(at time.Time) {
-			defer wg.Done()
-
-			if t, err := ws.GetForDateTime(ctx, at); err != nil {
-				log.S().Errorf("failed to obtain weather for datetime %s, %+v", at, err)
-			} else if t != nil {
-				tempChan <- *t
-			}
-		}(at)
-	}
-	wg.Wait()
-	close(tempChan)
-
-	temps := make(weathersSlice, 0)
-	for t := range tempChan {
-		temps = append(temps, t)
+	weathers := make([]Weather, len(results))
+	for i, r := range results {
+		weathers[i] = r.(Weather)
 	}
 
-	sort.Sort(temps)
-	return temps, nil
+	return weathers, nil
 }
 
 func (ws weatherService) GetForDateTime(ctx context.Context, at time.Time) (*Weather, error) {
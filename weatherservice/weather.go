@@ -1,24 +1,26 @@
 package weatherservice
 
-import "time"
+import (
+	"time"
+
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	"github.com/svranesevic/charlyedu/windspeedservice"
+)
 
 type Weather struct {
 	North       float64   `json:"north"`
 	West        float64   `json:"west"`
 	Temperature float64   `json:"temp"`
 	Date        time.Time `json:"date"`
-}
-
-type weathersSlice []Weather
-
-func (w weathersSlice) Len() int {
-	return len(w)
-}
 
-func (w weathersSlice) Less(i, j int) bool {
-	return w[i].Date.Before(w[j].Date)
+	// Derived wind properties are only set when they are requested.
+	*windspeedservice.Derived
+	// Comfort metrics are only set when they are requested.
+	*Comfort
 }
 
-func (w weathersSlice) Swap(i, j int) {
-	w[i], w[j] = w[j], w[i]
+// InLocation returns the reading dated at the same wall clock in loc.
+func (w Weather) InLocation(loc *time.Location) rangefetcher.Dated {
+	w.Date = rangefetcher.SameWallClock(w.Date, loc)
+	return w
 }
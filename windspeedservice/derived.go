@@ -0,0 +1,67 @@
+package windspeedservice
+
+import "math"
+
+// Derived holds the properties of the wind derived from its north and west
+// components, which are taken to be the velocities the wind blows towards
+// north and west with.
+type Derived struct {
+	// Speed is the magnitude of the wind in meters per second.
+	Speed float64 `json:"speed"`
+	// Direction is the meteorological direction the wind blows from, in
+	// degrees clockwise from north. Calm wind has a direction of 0.
+	Direction float64 `json:"direction"`
+	// Compass is the closest of the 16 compass points to Direction.
+	Compass             string `json:"compass"`
+	Beaufort            int    `json:"beaufort"`
+	BeaufortDescription string `json:"beaufort_description"`
+}
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// beaufortScale lists the upper bound in meters per second of every Beaufort
+// number but the last.
+var beaufortScale = []struct {
+	upTo        float64
+	description string
+}{
+	{0.5, "Calm"},
+	{1.6, "Light air"},
+	{3.4, "Light breeze"},
+	{5.5, "Gentle breeze"},
+	{8.0, "Moderate breeze"},
+	{10.8, "Fresh breeze"},
+	{13.9, "Strong breeze"},
+	{17.2, "Near gale"},
+	{20.8, "Gale"},
+	{24.5, "Strong gale"},
+	{28.5, "Storm"},
+	{32.7, "Violent storm"},
+	{math.Inf(1), "Hurricane force"},
+}
+
+func Derive(north float64, west float64) Derived {
+	speed := math.Hypot(north, west)
+
+	var direction float64
+	if speed > 0 {
+		// The wind blows from the opposite of where it blows towards, so
+		// from (-north, -west), which is at a bearing of atan2(west, -north)
+		// as east is the opposite of west.
+		direction = math.Mod(math.Atan2(west, -north)*180/math.Pi+360, 360)
+	}
+
+	d := Derived{
+		Speed:     speed,
+		Direction: direction,
+		Compass:   compassPoints[int(math.Floor(direction/22.5+0.5))%len(compassPoints)],
+	}
+	for i, b := range beaufortScale {
+		if speed < b.upTo {
+			d.Beaufort, d.BeaufortDescription = i, b.description
+			break
+		}
+	}
+
+	return d
+}
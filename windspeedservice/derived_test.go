@@ -0,0 +1,38 @@
+package windspeedservice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveDirectionIsWhereWindBlowsFrom(t *testing.T) {
+	cases := []struct {
+		north     float64
+		west      float64
+		direction float64
+		compass   string
+	}{
+		{north: -5, west: 0, direction: 0, compass: "N"},
+		{north: 0, west: 5, direction: 90, compass: "E"},
+		{north: 5, west: 0, direction: 180, compass: "S"},
+		{north: 0, west: -5, direction: 270, compass: "W"},
+		{north: -5, west: 5, direction: 45, compass: "NE"},
+	}
+
+	for _, c := range cases {
+		d := Derive(c.north, c.west)
+		assert.InDelta(t, c.direction, d.Direction, 0.0001)
+		assert.Equal(t, c.compass, d.Compass)
+	}
+}
+
+func TestDeriveBeaufort(t *testing.T) {
+	d := Derive(3, 4)
+	assert.Equal(t, 5.0, d.Speed)
+	assert.Equal(t, 3, d.Beaufort)
+	assert.Equal(t, "Gentle breeze", d.BeaufortDescription)
+
+	assert.Equal(t, 0, Derive(0, 0).Beaufort)
+	assert.Equal(t, 12, Derive(40, 0).Beaufort)
+}
@@ -3,91 +3,76 @@ package windspeedservice
 import (
 	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
-	"io/ioutil"
-	"math"
-	"net/http"
-	"sort"
-	"strings"
-	"sync"
 	"time"
 
-	log "go.uber.org/zap"
+	"github.com/svranesevic/charlyedu/daysource"
+	"github.com/svranesevic/charlyedu/rangefetcher"
+	"github.com/svranesevic/charlyedu/store"
+	"github.com/svranesevic/charlyedu/upstream"
 )
 
-type windSpeedService struct {
-	host string
-}
-
 type Service interface {
-	GetForRange(ctx context.Context, from time.Time, to time.Time) ([]WindSpeed, error)
+	GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]WindSpeed, error)
 	GetForDateTime(ctx context.Context, at time.Time) (*WindSpeed, error)
 }
 
-func New(host string) Service {
-	if strings.HasSuffix(host, "/") {
-		host = strings.TrimSuffix(host, "/")
-	}
-	return windSpeedService{host: host}
-}
-
-func (wss windSpeedService) GetForRange(ctx context.Context, from time.Time, to time.Time) ([]WindSpeed, error) {
-	if from.After(to) {
-		return []WindSpeed{}, errors.New("`start` must be before `end`")
-	}
-	to = to.Add(24 * time.Hour)
+const storeBucket = "wind_speed"
 
-	numDays := int(math.Ceil(to.Sub(from).Hours() / 24))
-	wsChan := make(chan WindSpeed, numDays)
-	var wg sync.WaitGroup
+// New returns a Source obtaining wind speed readings from the backing service
+// at host.
+func New(host string, opts ...upstream.Option) daysource.Source {
+	return daysource.Upstream(upstream.New(host, opts...), decode)
+}
 
-	for at := from; at.Before(to); at = at.Add(24 * time.Hour) {
-		wg.Add(1)
-		go func(at time.Time) {
-			defer wg.Done()
+// WithStore looks wind speed readings up in st before obtaining them through
+// s, and persists the readings of past days obtained through s in st.
+func WithStore(s daysource.Source, st *store.Store) daysource.Source {
+	return daysource.WithStore(s, st, storeBucket, decode)
+}
 
-			if ws, err := wss.GetForDateTime(ctx, at); err != nil {
-				log.S().Errorf("failed to obtain wind speed for datetime %s, %+v", at, err)
-			} else if ws != nil {
-				wsChan <- *ws
-			}
-		}(at)
-	}
-	wg.Wait()
-	close(wsChan)
+func decode(payload []byte) (interface{}, error) {
+	var r WindSpeed
+	err := json.Unmarshal(payload, &r)
+	return r, err
+}
 
-	windSpeeds := make(speedsSlice, 0)
-	for t := range wsChan {
-		windSpeeds = append(windSpeeds, t)
-	}
+type windSpeedService struct {
+	source  daysource.Source
+	fetcher rangefetcher.Fetcher
+}
 
-	sort.Sort(windSpeeds)
-	return windSpeeds, nil
+// NewService serves wind speed readings out of s, fetching each day of a
+// range through fetcher.
+func NewService(s daysource.Source, fetcher rangefetcher.Fetcher) Service {
+	return windSpeedService{source: s, fetcher: fetcher}
 }
 
 func (wss windSpeedService) GetForDateTime(ctx context.Context, at time.Time) (*WindSpeed, error) {
-	url := fmt.Sprintf("%s/?at=%s", wss.host, at.Format("2006-01-02T15:04:05Z0700"))
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
+	r, err := wss.source.GetForDateTime(ctx, at)
+	if err != nil || r == nil {
 		return nil, err
 	}
 
-	req = req.WithContext(ctx)
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
+	windSpeed := r.(WindSpeed)
+	return &windSpeed, nil
+}
 
-	bodyBytes, err := ioutil.ReadAll(res.Body)
+func (wss windSpeedService) GetForRange(ctx context.Context, from time.Time, to time.Time, opts ...rangefetcher.Option) ([]WindSpeed, error) {
+	results, err := wss.fetcher.FetchDays(ctx, from, to, func(ctx context.Context, at time.Time) (rangefetcher.Dated, error) {
+		r, err := wss.source.GetForDateTime(ctx, at)
+		if r == nil {
+			return nil, err
+		}
+		return r.(WindSpeed), err
+	}, opts...)
 	if err != nil {
-		return nil, err
+		return []WindSpeed{}, err
 	}
 
-	var t WindSpeed
-	if err = json.Unmarshal(bodyBytes, &t); err != nil {
-		return nil, err
+	windSpeeds := make([]WindSpeed, len(results))
+	for i, r := range results {
+		windSpeeds[i] = r.(WindSpeed)
 	}
 
-	return &t, nil
+	return windSpeeds, nil
 }
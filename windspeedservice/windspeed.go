@@ -1,23 +1,22 @@
 package windspeedservice
 
-import "time"
+import (
+	"time"
+
+	"github.com/svranesevic/charlyedu/rangefetcher"
+)
 
 type WindSpeed struct {
 	North float64   `json:"north"`
 	West  float64   `json:"west"`
 	Date  time.Time `json:"date"`
-}
-
-type speedsSlice []WindSpeed
-
-func (s speedsSlice) Len() int {
-	return len(s)
-}
 
-func (s speedsSlice) Less(i, j int) bool {
-	return s[i].Date.Before(s[j].Date)
+	// Derived is only set when it is requested.
+	*Derived
 }
 
-func (s speedsSlice) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
+// InLocation returns the reading dated at the same wall clock in loc.
+func (ws WindSpeed) InLocation(loc *time.Location) rangefetcher.Dated {
+	ws.Date = rangefetcher.SameWallClock(ws.Date, loc)
+	return ws
 }